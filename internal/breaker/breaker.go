@@ -0,0 +1,228 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"logisync/internal/providers"
+)
+
+const (
+	StateClosed   = "closed"
+	StateOpen     = "open"
+	StateHalfOpen = "half_open"
+)
+
+// allowScript lets calls through while closed. Once the open period has
+// elapsed, exactly one caller wins the probe key and moves the breaker to
+// half-open; everyone else is parked until the probe reports back.
+// Returns {allowed, state, retry_after_ms}.
+var allowScript = redis.NewScript(`
+local state = redis.call('HGET', KEYS[1], 'state') or 'closed'
+if state == 'closed' then
+  return {1, 'closed', 0}
+end
+
+local now = tonumber(ARGV[1])
+local reopen = tonumber(redis.call('HGET', KEYS[1], 'opened_at') or '0') + tonumber(ARGV[2])
+if state == 'open' and now < reopen then
+  return {0, 'open', reopen - now}
+end
+
+if redis.call('SET', KEYS[3], '1', 'NX', 'PX', ARGV[3]) then
+  redis.call('HSET', KEYS[1], 'state', 'half_open')
+  return {1, 'half_open', 0}
+end
+return {0, 'half_open', redis.call('PTTL', KEYS[3])}
+`)
+
+// recordScript feeds one outcome into the breaker and returns the new state.
+var recordScript = redis.NewScript(`
+local state = redis.call('HGET', KEYS[1], 'state') or 'closed'
+local failure = ARGV[2] == '1'
+
+if state == 'half_open' then
+  redis.call('DEL', KEYS[3])
+  if failure then
+    redis.call('HSET', KEYS[1], 'state', 'open', 'opened_at', ARGV[1])
+    return 'open'
+  end
+  redis.call('DEL', KEYS[1], KEYS[2])
+  return 'closed'
+end
+if state == 'open' then
+  return 'open'
+end
+
+local total = redis.call('HINCRBY', KEYS[2], 'total', 1)
+local failures = tonumber(redis.call('HGET', KEYS[2], 'failures') or '0')
+if failure then
+  failures = redis.call('HINCRBY', KEYS[2], 'failures', 1)
+end
+if total == 1 then
+  redis.call('PEXPIRE', KEYS[2], ARGV[3])
+end
+if total >= tonumber(ARGV[4]) and failures / total >= tonumber(ARGV[5]) then
+  redis.call('HSET', KEYS[1], 'state', 'open', 'opened_at', ARGV[1])
+  redis.call('DEL', KEYS[2])
+  return 'open'
+end
+return 'closed'
+`)
+
+type Config struct {
+	// Window is the period over which the failure rate is measured.
+	Window      time.Duration
+	MinRequests int
+	FailureRate float64
+	OpenFor     time.Duration
+	// ProbeTimeout bounds how long a half-open probe may hold the slot
+	// before another worker is allowed to try.
+	ProbeTimeout time.Duration
+	Prefix       string
+}
+
+type Decision struct {
+	Allowed    bool
+	State      string
+	RetryAfter time.Duration
+}
+
+type Status struct {
+	Provider string     `json:"provider"`
+	State    string     `json:"state"`
+	OpenedAt *time.Time `json:"opened_at,omitempty"`
+	Total    int64      `json:"window_total"`
+	Failures int64      `json:"window_failures"`
+}
+
+type Breaker struct {
+	redis *redis.Client
+	cfg   Config
+	now   func() time.Time
+}
+
+func New(addr string, cfg Config) *Breaker {
+	if cfg.Window <= 0 {
+		cfg.Window = time.Minute
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = 10
+	}
+	if cfg.FailureRate <= 0 || cfg.FailureRate > 1 {
+		cfg.FailureRate = 0.5
+	}
+	if cfg.OpenFor <= 0 {
+		cfg.OpenFor = 30 * time.Second
+	}
+	if cfg.ProbeTimeout <= 0 {
+		cfg.ProbeTimeout = 2 * time.Minute
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = "breaker"
+	}
+	return &Breaker{
+		redis: redis.NewClient(&redis.Options{Addr: addr}),
+		cfg:   cfg,
+		now:   time.Now,
+	}
+}
+
+func (b *Breaker) Close() error {
+	return b.redis.Close()
+}
+
+// IsFailure reports whether err counts against the provider: PROVIDER_ERROR,
+// TIMEOUT, or any error that is not a providers.Error.
+func IsFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	var providerErr *providers.Error
+	if !errors.As(err, &providerErr) {
+		return true
+	}
+	return providerErr.Code == "PROVIDER_ERROR" || providerErr.Code == "TIMEOUT"
+}
+
+func (b *Breaker) Allow(ctx context.Context, provider string) (Decision, error) {
+	res, err := allowScript.Run(ctx, b.redis, b.keys(provider),
+		b.now().UnixMilli(), b.cfg.OpenFor.Milliseconds(), b.cfg.ProbeTimeout.Milliseconds()).Slice()
+	if err != nil {
+		return Decision{}, fmt.Errorf("breaker allow %s: %w", provider, err)
+	}
+	if len(res) != 3 {
+		return Decision{}, fmt.Errorf("breaker allow %s: unexpected reply %v", provider, res)
+	}
+	allowed, _ := res[0].(int64)
+	state, _ := res[1].(string)
+	retryAfter, _ := res[2].(int64)
+	return Decision{
+		Allowed:    allowed == 1,
+		State:      state,
+		RetryAfter: time.Duration(retryAfter) * time.Millisecond,
+	}, nil
+}
+
+// Record reports the outcome of a call that Allow let through.
+func (b *Breaker) Record(ctx context.Context, provider string, err error) (string, error) {
+	failure := "0"
+	if IsFailure(err) {
+		failure = "1"
+	}
+	state, rerr := recordScript.Run(ctx, b.redis, b.keys(provider),
+		b.now().UnixMilli(), failure, b.cfg.Window.Milliseconds(), b.cfg.MinRequests, b.cfg.FailureRate).Text()
+	if rerr != nil {
+		return "", fmt.Errorf("breaker record %s: %w", provider, rerr)
+	}
+	return state, nil
+}
+
+func (b *Breaker) Status(ctx context.Context, provider string) (Status, error) {
+	keys := b.keys(provider)
+	pipe := b.redis.Pipeline()
+	stateCmd := pipe.HGetAll(ctx, keys[0])
+	windowCmd := pipe.HGetAll(ctx, keys[1])
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return Status{}, fmt.Errorf("breaker status %s: %w", provider, err)
+	}
+
+	status := Status{Provider: provider, State: StateClosed}
+	state := stateCmd.Val()
+	if s := state["state"]; s != "" {
+		status.State = s
+	}
+	if ms, err := strconv.ParseInt(state["opened_at"], 10, 64); err == nil && status.State != StateClosed {
+		openedAt := time.UnixMilli(ms).UTC()
+		status.OpenedAt = &openedAt
+	}
+	window := windowCmd.Val()
+	status.Total, _ = strconv.ParseInt(window["total"], 10, 64)
+	status.Failures, _ = strconv.ParseInt(window["failures"], 10, 64)
+	return status, nil
+}
+
+func (b *Breaker) Statuses(ctx context.Context, providerNames []string) ([]Status, error) {
+	statuses := make([]Status, 0, len(providerNames))
+	for _, name := range providerNames {
+		status, err := b.Status(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func (b *Breaker) keys(provider string) []string {
+	return []string{
+		b.cfg.Prefix + ":" + provider + ":state",
+		b.cfg.Prefix + ":" + provider + ":window",
+		b.cfg.Prefix + ":" + provider + ":probe",
+	}
+}
@@ -0,0 +1,111 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"logisync/internal/providers"
+)
+
+func newTestBreaker(t *testing.T, cfg Config) (*Breaker, *time.Time) {
+	t.Helper()
+	mini, err := miniredis.Run()
+	if err != nil {
+		t.Skipf("miniredis unavailable: %v", err)
+	}
+	t.Cleanup(mini.Close)
+
+	b := New(mini.Addr(), cfg)
+	t.Cleanup(func() { b.Close() })
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func TestIsFailure(t *testing.T) {
+	if IsFailure(nil) {
+		t.Fatalf("nil is not a failure")
+	}
+	if !IsFailure(&providers.Error{Code: "TIMEOUT"}) || !IsFailure(&providers.Error{Code: "PROVIDER_ERROR"}) {
+		t.Fatalf("expected TIMEOUT and PROVIDER_ERROR to count")
+	}
+	if IsFailure(&providers.Error{Code: "INVALID_INPUT"}) {
+		t.Fatalf("expected INVALID_INPUT not to count")
+	}
+	if !IsFailure(errors.New("boom")) {
+		t.Fatalf("expected plain errors to count")
+	}
+}
+
+func TestBreakerTripsAndRecovers(t *testing.T) {
+	b, now := newTestBreaker(t, Config{MinRequests: 4, FailureRate: 0.5, OpenFor: 30 * time.Second})
+	ctx := context.Background()
+	timeout := &providers.Error{Code: "TIMEOUT"}
+
+	for _, err := range []error{nil, &providers.Error{Code: "INVALID_INPUT"}, timeout} {
+		if state, rerr := b.Record(ctx, "mock_portal_scrape", err); rerr != nil || state != StateClosed {
+			t.Fatalf("expected closed, got %s %v", state, rerr)
+		}
+	}
+	state, err := b.Record(ctx, "mock_portal_scrape", timeout)
+	if err != nil || state != StateOpen {
+		t.Fatalf("expected breaker to open at 50%% failures, got %s %v", state, err)
+	}
+
+	decision, err := b.Allow(ctx, "mock_portal_scrape")
+	if err != nil {
+		t.Fatalf("allow: %v", err)
+	}
+	if decision.Allowed || decision.State != StateOpen || decision.RetryAfter != 30*time.Second {
+		t.Fatalf("expected parked job, got %+v", decision)
+	}
+
+	status, err := b.Status(ctx, "mock_portal_scrape")
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if status.State != StateOpen || status.OpenedAt == nil {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+
+	*now = now.Add(31 * time.Second)
+	probe, err := b.Allow(ctx, "mock_portal_scrape")
+	if err != nil || !probe.Allowed || probe.State != StateHalfOpen {
+		t.Fatalf("expected half-open probe, got %+v %v", probe, err)
+	}
+	second, err := b.Allow(ctx, "mock_portal_scrape")
+	if err != nil || second.Allowed {
+		t.Fatalf("expected only one probe, got %+v %v", second, err)
+	}
+
+	if state, err := b.Record(ctx, "mock_portal_scrape", nil); err != nil || state != StateClosed {
+		t.Fatalf("expected successful probe to close breaker, got %s %v", state, err)
+	}
+	if decision, err := b.Allow(ctx, "mock_portal_scrape"); err != nil || !decision.Allowed {
+		t.Fatalf("expected closed breaker to allow, got %+v %v", decision, err)
+	}
+}
+
+func TestBreakerFailedProbeReopens(t *testing.T) {
+	b, now := newTestBreaker(t, Config{MinRequests: 1, FailureRate: 1, OpenFor: time.Second})
+	ctx := context.Background()
+
+	if state, _ := b.Record(ctx, "mock_portal_scrape", errors.New("down")); state != StateOpen {
+		t.Fatalf("expected open, got %s", state)
+	}
+	*now = now.Add(2 * time.Second)
+	if decision, _ := b.Allow(ctx, "mock_portal_scrape"); !decision.Allowed {
+		t.Fatalf("expected probe to be allowed")
+	}
+	if state, _ := b.Record(ctx, "mock_portal_scrape", &providers.Error{Code: "PROVIDER_ERROR"}); state != StateOpen {
+		t.Fatalf("expected failed probe to reopen, got %s", state)
+	}
+	if decision, _ := b.Allow(ctx, "mock_portal_scrape"); decision.Allowed {
+		t.Fatalf("expected reopened breaker to park jobs")
+	}
+}
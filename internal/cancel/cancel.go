@@ -0,0 +1,104 @@
+package cancel
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Registry tracks the contexts of jobs running in this worker so they can be
+// cancelled by id.
+type Registry struct {
+	mu      sync.Mutex
+	running map[uuid.UUID]context.CancelFunc
+}
+
+func NewRegistry() *Registry {
+	return &Registry{running: map[uuid.UUID]context.CancelFunc{}}
+}
+
+// Track derives the context to pass into Provider.Track for jobID. The
+// returned release must be called once the job finishes.
+func (r *Registry) Track(ctx context.Context, jobID uuid.UUID) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	r.mu.Lock()
+	r.running[jobID] = cancel
+	r.mu.Unlock()
+
+	return ctx, func() {
+		r.mu.Lock()
+		delete(r.running, jobID)
+		r.mu.Unlock()
+		cancel()
+	}
+}
+
+// Cancel cancels jobID's context if it runs here and reports whether it did.
+func (r *Registry) Cancel(jobID uuid.UUID) bool {
+	r.mu.Lock()
+	cancel, ok := r.running[jobID]
+	r.mu.Unlock()
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// Bus broadcasts cancellations to every worker over a Redis pub/sub channel.
+// Messages are fire-and-forget: a worker that misses one lets the job run to
+// the end, and the CANCELLED status is kept because the repo refuses to
+// overwrite it.
+type Bus struct {
+	redis   *redis.Client
+	channel string
+}
+
+func New(addr, channel string) *Bus {
+	if channel == "" {
+		channel = "tracking:jobs:cancel"
+	}
+	return &Bus{redis: redis.NewClient(&redis.Options{Addr: addr}), channel: channel}
+}
+
+func (b *Bus) Close() error {
+	return b.redis.Close()
+}
+
+func (b *Bus) Publish(ctx context.Context, jobID uuid.UUID) error {
+	if err := b.redis.Publish(ctx, b.channel, jobID.String()).Err(); err != nil {
+		return fmt.Errorf("publish cancel: %w", err)
+	}
+	return nil
+}
+
+// Listen cancels jobs in reg as their ids arrive, until ctx is done. ready,
+// if not nil, is closed once the subscription is active.
+func (b *Bus) Listen(ctx context.Context, reg *Registry, ready chan<- struct{}) error {
+	sub := b.redis.Subscribe(ctx, b.channel)
+	defer sub.Close()
+
+	if _, err := sub.Receive(ctx); err != nil {
+		return fmt.Errorf("subscribe cancel: %w", err)
+	}
+	if ready != nil {
+		close(ready)
+	}
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-ch:
+			if !ok {
+				return fmt.Errorf("cancel subscription closed")
+			}
+			if id, err := uuid.Parse(msg.Payload); err == nil {
+				reg.Cancel(id)
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package cancel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+)
+
+func TestRegistry(t *testing.T) {
+	reg := NewRegistry()
+	id := uuid.New()
+
+	ctx, release := reg.Track(context.Background(), id)
+	if !reg.Cancel(id) {
+		t.Fatalf("expected running job to be cancelled")
+	}
+	if ctx.Err() != context.Canceled {
+		t.Fatalf("expected context to be cancelled, got %v", ctx.Err())
+	}
+
+	release()
+	if reg.Cancel(id) {
+		t.Fatalf("expected released job to be forgotten")
+	}
+	if reg.Cancel(uuid.New()) {
+		t.Fatalf("expected unknown job to report false")
+	}
+}
+
+func TestBusCancelsRunningJob(t *testing.T) {
+	mini, err := miniredis.Run()
+	if err != nil {
+		t.Skipf("miniredis unavailable: %v", err)
+	}
+	defer mini.Close()
+
+	bus := New(mini.Addr(), "")
+	defer bus.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	reg := NewRegistry()
+	id := uuid.New()
+	jobCtx, release := reg.Track(context.Background(), id)
+	defer release()
+
+	ready := make(chan struct{})
+	done := make(chan error, 1)
+	go func() { done <- bus.Listen(ctx, reg, ready) }()
+
+	select {
+	case <-ready:
+	case err := <-done:
+		t.Fatalf("listen: %v", err)
+	}
+	if err := bus.Publish(ctx, id); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	select {
+	case <-jobCtx.Done():
+	case <-ctx.Done():
+		t.Fatalf("job context was not cancelled")
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("expected listen to stop with context.Canceled, got %v", err)
+	}
+}
@@ -54,3 +54,42 @@ func TestEnvDurationInvalidFallback(t *testing.T) {
 		t.Fatalf("expected fallback timeout, got %s", cfg.OpTimeout)
 	}
 }
+
+func TestEnvIntInvalidFallback(t *testing.T) {
+	t.Setenv("DB_URL", "postgres://test")
+	t.Setenv("MAX_DELIVERIES", "lots")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.MaxDeliveries != 5 {
+		t.Fatalf("expected fallback max deliveries, got %d", cfg.MaxDeliveries)
+	}
+}
+
+func TestEnvIntMap(t *testing.T) {
+	t.Setenv("DB_URL", "postgres://test")
+	t.Setenv("RETRY_PROVIDER_MAX_ATTEMPTS", "mock_portal_scrape=3, dummy=1,broken,bad=x")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(cfg.RetryProviderMax) != 2 {
+		t.Fatalf("expected 2 entries, got %v", cfg.RetryProviderMax)
+	}
+	if cfg.RetryProviderMax["mock_portal_scrape"] != 3 || cfg.RetryProviderMax["dummy"] != 1 {
+		t.Fatalf("unexpected map: %v", cfg.RetryProviderMax)
+	}
+}
+
+func TestEnvFloatInvalidFallback(t *testing.T) {
+	t.Setenv("DB_URL", "postgres://test")
+	t.Setenv("BREAKER_FAILURE_RATE", "half")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.BreakerFailureRate != 0.5 {
+		t.Fatalf("expected fallback failure rate, got %v", cfg.BreakerFailureRate)
+	}
+}
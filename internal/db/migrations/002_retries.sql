@@ -0,0 +1,5 @@
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS jobs_retry_due_idx
+  ON jobs (next_attempt_at)
+  WHERE status = 'RETRY_SCHEDULED';
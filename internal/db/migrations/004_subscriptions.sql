@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS subscriptions (
+  id UUID PRIMARY KEY,
+  provider TEXT NOT NULL,
+  tracking_code TEXT NOT NULL,
+  status TEXT NOT NULL,
+  stop_reason TEXT,
+  interval_seconds INT NOT NULL,
+  current_interval_seconds INT NOT NULL,
+  max_interval_seconds INT NOT NULL,
+  max_polls INT NOT NULL DEFAULT 0,
+  polls INT NOT NULL DEFAULT 0,
+  terminal_statuses TEXT[] NOT NULL DEFAULT '{}',
+  expires_at TIMESTAMPTZ,
+  last_status TEXT,
+  last_job_id UUID REFERENCES jobs(id) ON DELETE SET NULL,
+  next_poll_at TIMESTAMPTZ NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS subscriptions_due_idx
+  ON subscriptions (next_poll_at)
+  WHERE status = 'ACTIVE';
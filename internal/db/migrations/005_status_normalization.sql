@@ -0,0 +1,28 @@
+ALTER TABLE tracking_results ADD COLUMN IF NOT EXISTS raw_status TEXT;
+ALTER TABLE tracking_results ADD COLUMN IF NOT EXISTS status TEXT;
+
+UPDATE tracking_results
+SET raw_status = normalized_payload->>'status'
+WHERE raw_status IS NULL;
+
+-- Older payloads already carried canonical names; anything else is left for
+-- the next lookup of the shipment to map.
+UPDATE tracking_results
+SET status = CASE
+  WHEN upper(raw_status) IN ('CREATED', 'IN_TRANSIT', 'OUT_FOR_DELIVERY', 'AWAITING_PICKUP',
+                             'DELIVERED', 'EXCEPTION', 'RETURNED') THEN upper(raw_status)
+  ELSE 'UNKNOWN'
+END
+WHERE status IS NULL;
+
+CREATE INDEX IF NOT EXISTS tracking_results_status_idx ON tracking_results (status);
+
+CREATE TABLE IF NOT EXISTS unmapped_statuses (
+  provider TEXT NOT NULL,
+  raw_status TEXT NOT NULL,
+  guessed_status TEXT NOT NULL,
+  occurrences INT NOT NULL DEFAULT 1,
+  first_seen_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  last_seen_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (provider, raw_status)
+);
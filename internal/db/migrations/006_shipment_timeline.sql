@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS shipments (
+  id UUID PRIMARY KEY,
+  provider TEXT NOT NULL,
+  tracking_code TEXT NOT NULL,
+  status TEXT NOT NULL,
+  raw_status TEXT,
+  last_update TIMESTAMPTZ,
+  last_job_id UUID REFERENCES jobs(id) ON DELETE SET NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  UNIQUE (provider, tracking_code)
+);
+
+CREATE TABLE IF NOT EXISTS shipment_events (
+  id UUID PRIMARY KEY,
+  shipment_id UUID NOT NULL REFERENCES shipments(id) ON DELETE CASCADE,
+  event_key TEXT NOT NULL,
+  occurred_at TIMESTAMPTZ NOT NULL,
+  description TEXT NOT NULL,
+  city TEXT,
+  state TEXT,
+  country TEXT,
+  status TEXT,
+  raw_status TEXT,
+  event_code TEXT,
+  first_seen_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  first_seen_job_id UUID REFERENCES jobs(id) ON DELETE SET NULL,
+  UNIQUE (shipment_id, event_key)
+);
+
+CREATE INDEX IF NOT EXISTS shipment_events_timeline_idx ON shipment_events (shipment_id, occurred_at);
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS jobs_lookup_idx ON jobs (provider, tracking_code, created_at DESC);
+CREATE INDEX IF NOT EXISTS tracking_results_lookup_idx ON tracking_results (provider, tracking_code, created_at DESC);
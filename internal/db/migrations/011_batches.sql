@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS batches (
+  id UUID PRIMARY KEY,
+  total INT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS batch_id UUID REFERENCES batches(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS jobs_batch_idx ON jobs (batch_id, id) WHERE batch_id IS NOT NULL;
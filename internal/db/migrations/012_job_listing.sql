@@ -0,0 +1,6 @@
+CREATE INDEX IF NOT EXISTS jobs_created_idx ON jobs (created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS jobs_status_created_idx ON jobs (status, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS jobs_provider_created_idx ON jobs (provider, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS jobs_tracking_code_created_idx ON jobs (tracking_code, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS jobs_error_code_created_idx ON jobs (error_code, created_at DESC, id DESC) WHERE error_code IS NOT NULL;
+CREATE INDEX IF NOT EXISTS jobs_updated_idx ON jobs (updated_at);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS job_attempts (
+  id UUID PRIMARY KEY,
+  job_id UUID NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+  attempt INT NOT NULL,
+  consumer TEXT NOT NULL,
+  message_id TEXT,
+  started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  finished_at TIMESTAMPTZ,
+  duration_ms BIGINT,
+  outcome TEXT NOT NULL,
+  error_code TEXT,
+  error_message TEXT
+);
+
+CREATE INDEX IF NOT EXISTS job_attempts_job_idx ON job_attempts (job_id, attempt);
+
+ALTER TABLE artifacts ADD COLUMN IF NOT EXISTS attempt_id UUID REFERENCES job_attempts(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS artifacts_attempt_idx ON artifacts (attempt_id) WHERE attempt_id IS NOT NULL;
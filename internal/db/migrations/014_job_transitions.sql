@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS job_transitions (
+  id BIGSERIAL PRIMARY KEY,
+  job_id UUID NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+  from_status TEXT NOT NULL,
+  to_status TEXT NOT NULL,
+  actor TEXT NOT NULL,
+  reason TEXT,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS job_transitions_job_idx ON job_transitions (job_id, id);
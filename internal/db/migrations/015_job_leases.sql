@@ -0,0 +1,4 @@
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS locked_by TEXT;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS lease_expires_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS jobs_lease_expires_idx ON jobs (lease_expires_at) WHERE status = 'RUNNING';
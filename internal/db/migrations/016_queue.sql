@@ -0,0 +1,40 @@
+-- Tables backing QUEUE_BACKEND=postgres.
+CREATE TABLE IF NOT EXISTS queue_messages (
+  id BIGSERIAL PRIMARY KEY,
+  stream TEXT NOT NULL,
+  payload JSONB NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS queue_groups (
+  stream TEXT NOT NULL,
+  name TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (stream, name)
+);
+
+-- One row per message and group; consumer is NULL until the message is read.
+CREATE TABLE IF NOT EXISTS queue_deliveries (
+  stream TEXT NOT NULL,
+  group_name TEXT NOT NULL,
+  message_id BIGINT NOT NULL REFERENCES queue_messages(id) ON DELETE CASCADE,
+  consumer TEXT,
+  delivered_at TIMESTAMPTZ,
+  delivery_count BIGINT NOT NULL DEFAULT 0,
+  PRIMARY KEY (stream, group_name, message_id)
+);
+
+CREATE INDEX IF NOT EXISTS queue_deliveries_pending_idx ON queue_deliveries (stream, group_name, delivered_at)
+  WHERE consumer IS NOT NULL;
+CREATE INDEX IF NOT EXISTS queue_deliveries_message_idx ON queue_deliveries (message_id);
+
+CREATE OR REPLACE FUNCTION queue_notify() RETURNS trigger AS $$
+BEGIN
+  PERFORM pg_notify('logisync_queue', NEW.stream);
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS queue_messages_notify ON queue_messages;
+CREATE TRIGGER queue_messages_notify AFTER INSERT ON queue_messages
+  FOR EACH ROW EXECUTE FUNCTION queue_notify();
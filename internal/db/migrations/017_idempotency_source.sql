@@ -0,0 +1,3 @@
+-- How the original submission was satisfied, so replays report the same
+-- source. Keys stored before this migration replay as coalesced.
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS source TEXT;
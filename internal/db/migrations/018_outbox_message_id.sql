@@ -0,0 +1,3 @@
+-- The stream entry id each outbox row was published as, so the reconciler
+-- can tell a job still waiting in the queue from one whose message was lost.
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS message_id TEXT;
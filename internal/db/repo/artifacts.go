@@ -17,10 +17,15 @@ func NewArtifactRepo(pool *pgxpool.Pool) *ArtifactRepo {
 }
 
 func (r *ArtifactRepo) Insert(ctx context.Context, jobID uuid.UUID, provider, key, kind string) error {
+	return r.InsertForAttempt(ctx, jobID, nil, provider, key, kind)
+}
+
+// InsertForAttempt links the artifact to the job attempt that produced it.
+func (r *ArtifactRepo) InsertForAttempt(ctx context.Context, jobID uuid.UUID, attemptID *uuid.UUID, provider, key, kind string) error {
 	_, err := r.pool.Exec(ctx, `
-		INSERT INTO artifacts (id, job_id, provider, artifact_key, kind)
-		VALUES (gen_random_uuid(), $1, $2, $3, $4)
-	`, jobID, provider, key, kind)
+		INSERT INTO artifacts (id, job_id, attempt_id, provider, artifact_key, kind)
+		VALUES (gen_random_uuid(), $1, $2, $3, $4, $5)
+	`, jobID, attemptID, provider, key, kind)
 	if err != nil {
 		return fmt.Errorf("insert artifact: %w", err)
 	}
@@ -0,0 +1,141 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Attempt outcomes. RUNNING is the outcome until the attempt finishes.
+const (
+	AttemptRunning        = "RUNNING"
+	AttemptSucceeded      = "SUCCEEDED"
+	AttemptFailed         = "FAILED"
+	AttemptRetryScheduled = "RETRY_SCHEDULED"
+	AttemptCancelled      = "CANCELLED"
+)
+
+type AttemptArtifact struct {
+	Key  string `json:"artifact_key"`
+	Kind string `json:"kind"`
+}
+
+type JobAttempt struct {
+	ID           uuid.UUID         `json:"attempt_id"`
+	JobID        uuid.UUID         `json:"job_id"`
+	Attempt      int               `json:"attempt"`
+	Consumer     string            `json:"consumer"`
+	MessageID    *string           `json:"message_id,omitempty"`
+	StartedAt    time.Time         `json:"started_at"`
+	FinishedAt   *time.Time        `json:"finished_at,omitempty"`
+	DurationMs   *int64            `json:"duration_ms,omitempty"`
+	Outcome      string            `json:"outcome"`
+	ErrorCode    *string           `json:"error_code,omitempty"`
+	ErrorMessage *string           `json:"error_message,omitempty"`
+	Artifacts    []AttemptArtifact `json:"artifacts"`
+}
+
+type AttemptRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewAttemptRepo(pool *pgxpool.Pool) *AttemptRepo {
+	return &AttemptRepo{pool: pool}
+}
+
+// Start records an attempt right after JobRepo.MarkRunning; the attempt
+// number is the job's current attempts counter, which starts over when the
+// job is requeued.
+func (r *AttemptRepo) Start(ctx context.Context, jobID uuid.UUID, consumer, messageID string) (JobAttempt, error) {
+	attempt := JobAttempt{JobID: jobID, Consumer: consumer, Outcome: AttemptRunning, Artifacts: []AttemptArtifact{}}
+	if messageID != "" {
+		attempt.MessageID = &messageID
+	}
+	err := r.pool.QueryRow(ctx, `
+		INSERT INTO job_attempts (id, job_id, attempt, consumer, message_id, outcome)
+		SELECT gen_random_uuid(), id, attempts, $2, $3, $4
+		FROM jobs
+		WHERE id = $1
+		RETURNING id, attempt, started_at
+	`, jobID, consumer, attempt.MessageID, AttemptRunning).Scan(&attempt.ID, &attempt.Attempt, &attempt.StartedAt)
+	if err != nil {
+		return JobAttempt{}, fmt.Errorf("start attempt: %w", err)
+	}
+	return attempt, nil
+}
+
+// Finish closes a RUNNING attempt with its outcome. code and message are
+// stored only when non-empty.
+func (r *AttemptRepo) Finish(ctx context.Context, id uuid.UUID, outcome, code, message string) error {
+	cmd, err := r.pool.Exec(ctx, `
+		UPDATE job_attempts
+		SET outcome = $2, error_code = NULLIF($3, ''), error_message = NULLIF($4, ''),
+			finished_at = now(), duration_ms = (extract(epoch FROM now() - started_at) * 1000)::bigint
+		WHERE id = $1 AND outcome = 'RUNNING'
+	`, id, outcome, code, message)
+	if err != nil {
+		return fmt.Errorf("finish attempt: %w", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// FinishOpen closes every RUNNING attempt of the job, e.g. one abandoned by a
+// worker whose lease expired, and returns how many it closed.
+func (r *AttemptRepo) FinishOpen(ctx context.Context, jobID uuid.UUID, outcome, code, message string) (int64, error) {
+	cmd, err := r.pool.Exec(ctx, `
+		UPDATE job_attempts
+		SET outcome = $2, error_code = NULLIF($3, ''), error_message = NULLIF($4, ''),
+			finished_at = now(), duration_ms = (extract(epoch FROM now() - started_at) * 1000)::bigint
+		WHERE job_id = $1 AND outcome = 'RUNNING'
+	`, jobID, outcome, code, message)
+	if err != nil {
+		return 0, fmt.Errorf("finish open attempts: %w", err)
+	}
+	return cmd.RowsAffected(), nil
+}
+
+func (r *AttemptRepo) ListByJob(ctx context.Context, jobID uuid.UUID) ([]JobAttempt, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT a.id, a.job_id, a.attempt, a.consumer, a.message_id, a.started_at, a.finished_at, a.duration_ms,
+			a.outcome, a.error_code, a.error_message,
+			COALESCE(array_agg(ar.artifact_key ORDER BY ar.created_at) FILTER (WHERE ar.id IS NOT NULL), '{}'),
+			COALESCE(array_agg(ar.kind ORDER BY ar.created_at) FILTER (WHERE ar.id IS NOT NULL), '{}')
+		FROM job_attempts a
+		LEFT JOIN artifacts ar ON ar.attempt_id = a.id
+		WHERE a.job_id = $1
+		GROUP BY a.id
+		ORDER BY a.started_at, a.attempt
+	`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("list attempts: %w", err)
+	}
+	defer rows.Close()
+
+	attempts := []JobAttempt{}
+	for rows.Next() {
+		var a JobAttempt
+		var keys, kinds []string
+		if err := rows.Scan(
+			&a.ID, &a.JobID, &a.Attempt, &a.Consumer, &a.MessageID, &a.StartedAt, &a.FinishedAt, &a.DurationMs,
+			&a.Outcome, &a.ErrorCode, &a.ErrorMessage, &keys, &kinds,
+		); err != nil {
+			return nil, fmt.Errorf("scan attempt: %w", err)
+		}
+		a.Artifacts = make([]AttemptArtifact, len(keys))
+		for i := range keys {
+			a.Artifacts[i] = AttemptArtifact{Key: keys[i], Kind: kinds[i]}
+		}
+		attempts = append(attempts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list attempts: %w", err)
+	}
+	return attempts, nil
+}
@@ -0,0 +1,186 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Batch struct {
+	ID        uuid.UUID `json:"batch_id"`
+	Total     int       `json:"total"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type BatchSummary struct {
+	Batch
+	Counts map[string]int `json:"counts"`
+	// Finished counts jobs in a terminal state (DONE, FAILED or CANCELLED).
+	Finished int     `json:"finished"`
+	Progress float64 `json:"progress"`
+}
+
+type BatchJob struct {
+	Job
+	Result *TrackingResult `json:"result,omitempty"`
+}
+
+type BatchRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewBatchRepo(pool *pgxpool.Pool) *BatchRepo {
+	return &BatchRepo{pool: pool}
+}
+
+// Create stores the batch and its jobs, each with an outbox message built by
+// message, in one transaction. Rows are written with COPY so manifests of a
+// few thousand codes stay a handful of round trips.
+func (r *BatchRepo) Create(ctx context.Context, batch Batch, jobs []Job, stream string, message func(Job) map[string]any) (Batch, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return Batch{}, fmt.Errorf("begin create batch: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO batches (id, total)
+		VALUES ($1, $2)
+		RETURNING created_at
+	`, batch.ID, len(jobs)).Scan(&batch.CreatedAt)
+	if err != nil {
+		return Batch{}, fmt.Errorf("insert batch: %w", err)
+	}
+	batch.Total = len(jobs)
+
+	jobRows := make([][]any, len(jobs))
+	outboxRows := make([][]any, len(jobs))
+	for i, job := range jobs {
+		jobRows[i] = []any{job.ID, job.Provider, job.TrackingCode, job.Status, job.Attempts, batch.ID}
+		payload, err := json.Marshal(message(job))
+		if err != nil {
+			return Batch{}, fmt.Errorf("marshal outbox payload: %w", err)
+		}
+		outboxRows[i] = []any{job.ID, stream, payload}
+	}
+
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"jobs"},
+		[]string{"id", "provider", "tracking_code", "status", "attempts", "batch_id"},
+		pgx.CopyFromRows(jobRows)); err != nil {
+		return Batch{}, fmt.Errorf("copy batch jobs: %w", err)
+	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"outbox"},
+		[]string{"job_id", "stream", "payload"},
+		pgx.CopyFromRows(outboxRows)); err != nil {
+		return Batch{}, fmt.Errorf("copy batch outbox: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Batch{}, fmt.Errorf("commit create batch: %w", err)
+	}
+	return batch, nil
+}
+
+func (r *BatchRepo) Summary(ctx context.Context, id uuid.UUID) (BatchSummary, error) {
+	var summary BatchSummary
+	err := r.pool.QueryRow(ctx, `
+		SELECT id, total, created_at FROM batches WHERE id = $1
+	`, id).Scan(&summary.ID, &summary.Total, &summary.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return BatchSummary{}, err
+		}
+		return BatchSummary{}, fmt.Errorf("get batch: %w", err)
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT status, count(*) FROM jobs WHERE batch_id = $1 GROUP BY status
+	`, id)
+	if err != nil {
+		return BatchSummary{}, fmt.Errorf("count batch jobs: %w", err)
+	}
+	defer rows.Close()
+
+	summary.Counts = map[string]int{}
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return BatchSummary{}, fmt.Errorf("scan batch count: %w", err)
+		}
+		summary.Counts[status] = count
+		if status == "DONE" || status == "FAILED" || status == "CANCELLED" {
+			summary.Finished += count
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return BatchSummary{}, fmt.Errorf("count batch jobs: %w", err)
+	}
+	if summary.Total > 0 {
+		summary.Progress = float64(summary.Finished) * 100 / float64(summary.Total)
+	}
+	return summary, nil
+}
+
+// ListJobs pages through a batch's jobs by id, with each job's latest
+// result. Pass the last id of the previous page as after, or nil to start.
+func (r *BatchRepo) ListJobs(ctx context.Context, id uuid.UUID, after *uuid.UUID, limit int) ([]BatchJob, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT j.id, j.provider, j.tracking_code, j.status, j.attempts, j.error_code, j.error_message,
+			j.next_attempt_at, j.scheduled_for, j.batch_id, j.locked_by, j.lease_expires_at, j.created_at, j.updated_at,
+			r.job_id, r.provider, r.tracking_code, COALESCE(r.status, ''), COALESCE(r.raw_status, ''),
+			r.normalized_payload, r.created_at
+		FROM jobs j
+		LEFT JOIN LATERAL (
+			SELECT * FROM tracking_results tr
+			WHERE tr.job_id = j.id
+			ORDER BY tr.created_at DESC
+			LIMIT 1
+		) r ON true
+		WHERE j.batch_id = $1 AND ($2::uuid IS NULL OR j.id > $2)
+		ORDER BY j.id
+		LIMIT $3
+	`, id, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list batch jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []BatchJob
+	for rows.Next() {
+		var bj BatchJob
+		var resJobID *uuid.UUID
+		var resProvider, resCode, resStatus, resRaw *string
+		var resPayload []byte
+		var resCreated *time.Time
+		if err := rows.Scan(
+			&bj.ID, &bj.Provider, &bj.TrackingCode, &bj.Status, &bj.Attempts, &bj.ErrorCode, &bj.ErrorMessage,
+			&bj.NextAttemptAt, &bj.ScheduledFor, &bj.BatchID, &bj.LockedBy, &bj.LeaseExpiresAt, &bj.CreatedAt, &bj.UpdatedAt,
+			&resJobID, &resProvider, &resCode, &resStatus, &resRaw, &resPayload, &resCreated,
+		); err != nil {
+			return nil, fmt.Errorf("scan batch job: %w", err)
+		}
+		if resJobID != nil {
+			bj.Result = &TrackingResult{
+				JobID:             *resJobID,
+				Provider:          *resProvider,
+				TrackingCode:      *resCode,
+				Status:            *resStatus,
+				RawStatus:         *resRaw,
+				NormalizedPayload: resPayload,
+				CreatedAt:         resCreated.Format(time.RFC3339),
+			}
+		}
+		jobs = append(jobs, bj)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list batch jobs: %w", err)
+	}
+	return jobs, nil
+}
@@ -0,0 +1,38 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrJobCancelled matches the TransitionError returned by the Mark*
+	// methods when the job was cancelled; the worker should drop it.
+	ErrJobCancelled = errors.New("job cancelled")
+	// ErrNotCancellable is returned by Cancel for jobs already finished.
+	ErrNotCancellable = errors.New("job is not cancellable")
+)
+
+// Cancel moves a PENDING, RETRY_SCHEDULED or RUNNING job to CANCELLED and
+// returns it with the status it had before. Cancelling a cancelled job is a
+// no-op that reports CANCELLED as the previous status.
+func (r *JobRepo) Cancel(ctx context.Context, id uuid.UUID, change Change) (Job, string, error) {
+	job, previous, err := r.transition(ctx, id, JobCancelled, nil, change, `next_attempt_at = NULL,`+clearLease)
+	if err == nil {
+		return job, previous, nil
+	}
+	if !errors.Is(err, ErrIllegalTransition) {
+		return Job{}, previous, err
+	}
+	if previous == JobCancelled {
+		job, err := r.Get(ctx, id)
+		if err != nil {
+			return Job{}, previous, fmt.Errorf("get job: %w", err)
+		}
+		return job, previous, nil
+	}
+	return Job{}, previous, fmt.Errorf("%w: %w", ErrNotCancellable, err)
+}
@@ -0,0 +1,131 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Sources say how a submission was satisfied.
+const (
+	SourceFresh     = "fresh"
+	SourceCoalesced = "coalesced"
+	SourceCached    = "cached"
+)
+
+type Submission struct {
+	Job     Job             `json:"job"`
+	Source  string          `json:"source"`
+	Created bool            `json:"-"`
+	Result  *TrackingResult `json:"result,omitempty"`
+}
+
+// Submit is CreateWithOutbox with coalescing: a result for the same provider
+// and tracking code younger than freshFor is returned as cached, otherwise a
+// PENDING/RUNNING job for the pair is returned as coalesced, and only then is
+// a new job created. Submissions for the same pair are serialized with an
+// advisory lock. Scheduled jobs are never coalesced; freshFor <= 0 disables
+// the cache. Idempotency replays report the source of the original
+// submission, with Created unset.
+func (r *JobRepo) Submit(ctx context.Context, job Job, key *IdempotencyKey, stream string, values map[string]any, freshFor time.Duration) (Submission, error) {
+	if job.ScheduledFor != nil {
+		created, ok, err := r.create(ctx, job, key, stream, values)
+		if err != nil {
+			return Submission{}, err
+		}
+		// Scheduled jobs are always created, so a replay is fresh as well.
+		return Submission{Job: created, Source: SourceFresh, Created: ok}, nil
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return Submission{}, fmt.Errorf("begin submit: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1), hashtext($2))`, job.Provider, job.TrackingCode); err != nil {
+		return Submission{}, fmt.Errorf("lock lookup: %w", err)
+	}
+	sub, found, err := findExisting(ctx, tx, job.Provider, job.TrackingCode, freshFor)
+	if err != nil {
+		return Submission{}, err
+	}
+	target, source := sub.Job.ID, sub.Source
+	if !found {
+		if err := insertJob(ctx, tx, job); err != nil {
+			return Submission{}, err
+		}
+		target, source = job.ID, SourceFresh
+	}
+	if key != nil {
+		claimed, err := claimIdempotencyKey(ctx, tx, *key, target, source)
+		if err != nil {
+			return Submission{}, err
+		}
+		if !claimed {
+			if err := tx.Rollback(ctx); err != nil {
+				return Submission{}, fmt.Errorf("rollback submit: %w", err)
+			}
+			return r.replay(ctx, *key)
+		}
+	}
+
+	if !found {
+		if values != nil {
+			if err := insertOutbox(ctx, tx, job.ID, stream, values); err != nil {
+				return Submission{}, err
+			}
+		}
+		created, err := scanJob(tx.QueryRow(ctx, `SELECT `+jobColumns+` FROM jobs WHERE id = $1`, job.ID))
+		if err != nil {
+			return Submission{}, fmt.Errorf("get created job: %w", err)
+		}
+		sub = Submission{Job: created, Source: SourceFresh, Created: true}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return Submission{}, fmt.Errorf("commit submit: %w", err)
+	}
+	return sub, nil
+}
+
+func findExisting(ctx context.Context, tx pgx.Tx, provider, trackingCode string, freshFor time.Duration) (Submission, bool, error) {
+	if freshFor > 0 {
+		result, err := scanResult(tx.QueryRow(ctx, `
+			SELECT `+resultColumns+`
+			FROM tracking_results
+			WHERE provider = $1 AND tracking_code = $2 AND created_at >= now() - $3 * interval '1 second'
+			ORDER BY created_at DESC
+			LIMIT 1
+		`, provider, trackingCode, freshFor.Seconds()))
+		switch {
+		case err == nil:
+			job, err := scanJob(tx.QueryRow(ctx, `SELECT `+jobColumns+` FROM jobs WHERE id = $1`, result.JobID))
+			if err != nil {
+				return Submission{}, false, fmt.Errorf("get cached job: %w", err)
+			}
+			return Submission{Job: job, Source: SourceCached, Result: &result}, true, nil
+		case !errors.Is(err, pgx.ErrNoRows):
+			return Submission{}, false, fmt.Errorf("find fresh result: %w", err)
+		}
+	}
+
+	job, err := scanJob(tx.QueryRow(ctx, `
+		SELECT `+jobColumns+`
+		FROM jobs
+		WHERE provider = $1 AND tracking_code = $2 AND status IN ('PENDING', 'RUNNING')
+			AND (scheduled_for IS NULL OR scheduled_for <= now())
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, provider, trackingCode))
+	switch {
+	case err == nil:
+		return Submission{Job: job, Source: SourceCoalesced}, true, nil
+	case errors.Is(err, pgx.ErrNoRows):
+		return Submission{}, false, nil
+	default:
+		return Submission{}, false, fmt.Errorf("find active job: %w", err)
+	}
+}
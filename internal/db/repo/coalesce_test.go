@@ -0,0 +1,71 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSubmitSources(t *testing.T) {
+	pool := testPool(t)
+	jobs := NewJobRepo(pool)
+	ctx := context.Background()
+
+	provider := "coalesce-test-" + uuid.NewString()
+	t.Cleanup(func() { pool.Exec(context.Background(), `DELETE FROM jobs WHERE provider = $1`, provider) })
+	newJob := func() Job {
+		return Job{ID: uuid.New(), Provider: provider, TrackingCode: "AA123", Status: JobPending}
+	}
+	newKey := func() *IdempotencyKey {
+		return &IdempotencyKey{Key: "coalesce-test-" + uuid.NewString(), RequestHash: HashRequest([]byte("{}")), Retention: time.Hour}
+	}
+	submit := func(job Job, key *IdempotencyKey) Submission {
+		t.Helper()
+		sub, err := jobs.Submit(ctx, job, key, "", nil, time.Hour)
+		if err != nil {
+			t.Fatalf("submit: %v", err)
+		}
+		return sub
+	}
+
+	freshKey := newKey()
+	fresh := submit(newJob(), freshKey)
+	if fresh.Source != SourceFresh || !fresh.Created {
+		t.Fatalf("expected a fresh job, got %s (created=%v)", fresh.Source, fresh.Created)
+	}
+
+	coalescedKey := newKey()
+	coalesced := submit(newJob(), coalescedKey)
+	if coalesced.Source != SourceCoalesced || coalesced.Created || coalesced.Job.ID != fresh.Job.ID {
+		t.Fatalf("expected to coalesce onto %s, got %s %s", fresh.Job.ID, coalesced.Source, coalesced.Job.ID)
+	}
+
+	if _, err := pool.Exec(ctx, `
+		INSERT INTO tracking_results (id, job_id, provider, tracking_code, normalized_payload, status, raw_status)
+		VALUES (gen_random_uuid(), $1, $2, 'AA123', '{}', 'DELIVERED', 'Delivered')
+	`, fresh.Job.ID, provider); err != nil {
+		t.Fatalf("insert result: %v", err)
+	}
+	cachedKey := newKey()
+	cached := submit(newJob(), cachedKey)
+	if cached.Source != SourceCached || cached.Created || cached.Result == nil || cached.Result.Status != "DELIVERED" {
+		t.Fatalf("expected a cached result, got %+v", cached)
+	}
+
+	// Replays answer with the original source, whatever the state is now.
+	for key, want := range map[*IdempotencyKey]string{
+		freshKey:     SourceFresh,
+		coalescedKey: SourceCoalesced,
+		cachedKey:    SourceCached,
+	} {
+		replayed := submit(newJob(), key)
+		if replayed.Source != want || replayed.Created || replayed.Job.ID != fresh.Job.ID {
+			t.Fatalf("replay of a %s submission: got %s (created=%v, job %s)", want, replayed.Source, replayed.Created, replayed.Job.ID)
+		}
+		if want == SourceCached && replayed.Result == nil {
+			t.Fatalf("expected the cached replay to carry the result")
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package repo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrIdempotencyMismatch = errors.New("idempotency key reused with a different request")
+
+type IdempotencyKey struct {
+	Key string
+	// RequestHash fingerprints the request body; see HashRequest.
+	RequestHash string
+	// Retention is how long the key keeps answering replays.
+	Retention time.Duration
+}
+
+func HashRequest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// claimIdempotencyKey binds key to jobID and the submission's source unless
+// a live binding exists. Expired bindings are taken over.
+func claimIdempotencyKey(ctx context.Context, tx pgx.Tx, key IdempotencyKey, jobID uuid.UUID, source string) (bool, error) {
+	var claimed uuid.UUID
+	err := tx.QueryRow(ctx, `
+		INSERT INTO idempotency_keys (key, request_hash, job_id, source, expires_at)
+		VALUES ($1, $2, $3, $4, now() + $5 * interval '1 second')
+		ON CONFLICT (key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash, job_id = EXCLUDED.job_id, source = EXCLUDED.source,
+			created_at = now(), expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= now()
+		RETURNING job_id
+	`, key.Key, key.RequestHash, jobID, source, key.Retention.Seconds()).Scan(&claimed)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("claim idempotency key: %w", err)
+	}
+	return true, nil
+}
+
+// replay answers a repeated request with the submission the key was first
+// bound to, under its original source. A cached replay carries the job's
+// latest result.
+func (r *JobRepo) replay(ctx context.Context, key IdempotencyKey) (Submission, error) {
+	var hash string
+	var source *string
+	var jobID uuid.UUID
+	err := r.pool.QueryRow(ctx, `
+		SELECT request_hash, job_id, source FROM idempotency_keys WHERE key = $1
+	`, key.Key).Scan(&hash, &jobID, &source)
+	if err != nil {
+		return Submission{}, fmt.Errorf("get idempotency key: %w", err)
+	}
+	if hash != key.RequestHash {
+		return Submission{}, ErrIdempotencyMismatch
+	}
+	job, err := r.Get(ctx, jobID)
+	if err != nil {
+		return Submission{}, fmt.Errorf("get replayed job: %w", err)
+	}
+
+	sub := Submission{Job: job, Source: SourceCoalesced}
+	if source != nil {
+		sub.Source = *source
+	}
+	if sub.Source == SourceCached {
+		result, err := scanResult(r.pool.QueryRow(ctx, `
+			SELECT `+resultColumns+`
+			FROM tracking_results
+			WHERE job_id = $1
+			ORDER BY created_at DESC
+			LIMIT 1
+		`, jobID))
+		if err != nil {
+			return Submission{}, fmt.Errorf("get replayed result: %w", err)
+		}
+		sub.Result = &result
+	}
+	return sub, nil
+}
+
+// PurgeIdempotencyKeys deletes keys whose retention has passed.
+func (r *JobRepo) PurgeIdempotencyKeys(ctx context.Context) (int64, error) {
+	cmd, err := r.pool.Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= now()`)
+	if err != nil {
+		return 0, fmt.Errorf("purge idempotency keys: %w", err)
+	}
+	return cmd.RowsAffected(), nil
+}
@@ -0,0 +1,149 @@
+package repo
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 500
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// JobCursor marks the last job of a page; jobs are listed newest first.
+type JobCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+func (c JobCursor) Encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeJobCursor(s string) (JobCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return JobCursor{}, ErrInvalidCursor
+	}
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return JobCursor{}, ErrInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return JobCursor{}, ErrInvalidCursor
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return JobCursor{}, ErrInvalidCursor
+	}
+	return JobCursor{CreatedAt: createdAt, ID: parsed}, nil
+}
+
+// JobFilter narrows JobRepo.List. Zero fields are ignored; time ranges are
+// inclusive of the lower bound and exclusive of the upper.
+type JobFilter struct {
+	Statuses      []string
+	Provider      string
+	TrackingCode  string
+	ErrorCode     string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	After         *JobCursor
+	Limit         int
+}
+
+// conditions renders the filter as SQL predicates with positional args.
+func (f JobFilter) conditions() ([]string, []any) {
+	var where []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if len(f.Statuses) > 0 {
+		add("status = ANY($%d)", f.Statuses)
+	}
+	if f.Provider != "" {
+		add("provider = $%d", f.Provider)
+	}
+	if f.TrackingCode != "" {
+		add("tracking_code = $%d", f.TrackingCode)
+	}
+	if f.ErrorCode != "" {
+		add("error_code = $%d", f.ErrorCode)
+	}
+	if f.CreatedAfter != nil {
+		add("created_at >= $%d", *f.CreatedAfter)
+	}
+	if f.CreatedBefore != nil {
+		add("created_at < $%d", *f.CreatedBefore)
+	}
+	if f.UpdatedAfter != nil {
+		add("updated_at >= $%d", *f.UpdatedAfter)
+	}
+	if f.UpdatedBefore != nil {
+		add("updated_at < $%d", *f.UpdatedBefore)
+	}
+	if f.After != nil {
+		args = append(args, f.After.CreatedAt, f.After.ID)
+		where = append(where, fmt.Sprintf("(created_at, id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+	return where, args
+}
+
+// List returns a page of jobs matching f, newest first, and the cursor for
+// the next page (nil on the last page).
+func (r *JobRepo) List(ctx context.Context, f JobFilter) ([]Job, *JobCursor, error) {
+	limit := f.Limit
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	if limit > MaxListLimit {
+		limit = MaxListLimit
+	}
+
+	where, args := f.conditions()
+	query := `SELECT ` + jobColumns + ` FROM jobs`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	args = append(args, limit+1)
+	query += fmt.Sprintf(` ORDER BY created_at DESC, id DESC LIMIT $%d`, len(args))
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, nil, fmt.Errorf("scan job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("list jobs: %w", err)
+	}
+
+	if len(jobs) <= limit {
+		return jobs, nil, nil
+	}
+	jobs = jobs[:limit]
+	last := jobs[limit-1]
+	return jobs, &JobCursor{CreatedAt: last.CreatedAt, ID: last.ID}, nil
+}
@@ -0,0 +1,102 @@
+package repo
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestJobCursorRoundTrip(t *testing.T) {
+	for _, createdAt := range []time.Time{
+		time.Date(2024, 1, 2, 10, 30, 0, 123456000, time.UTC),
+		time.Date(2024, 1, 2, 10, 30, 0, 0, time.FixedZone("BRT", -3*3600)),
+	} {
+		cursor := JobCursor{CreatedAt: createdAt, ID: uuid.New()}
+		decoded, err := DecodeJobCursor(cursor.Encode())
+		if err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if !decoded.CreatedAt.Equal(createdAt) || decoded.ID != cursor.ID {
+			t.Fatalf("expected %+v, got %+v", cursor, decoded)
+		}
+	}
+}
+
+func TestDecodeJobCursorRejectsMalformed(t *testing.T) {
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+	for _, s := range []string{
+		"",
+		"not base64!",
+		encode("2024-01-02T10:30:00Z"),
+		encode("yesterday|" + uuid.NewString()),
+		encode("2024-01-02T10:30:00Z|not-a-uuid"),
+	} {
+		if _, err := DecodeJobCursor(s); !errors.Is(err, ErrInvalidCursor) {
+			t.Fatalf("decode %q: expected ErrInvalidCursor, got %v", s, err)
+		}
+	}
+}
+
+func TestListPaginatesThroughCreatedAtTies(t *testing.T) {
+	pool := testPool(t)
+	jobRepo := NewJobRepo(pool)
+	ctx := context.Background()
+
+	provider := "list-test-" + uuid.NewString()
+	tie := time.Date(2024, 1, 2, 10, 30, 0, 123456000, time.UTC)
+	createdAt := []time.Time{tie.Add(time.Second), tie, tie, tie, tie, tie.Add(-time.Second)}
+	for _, at := range createdAt {
+		if _, err := pool.Exec(ctx, `
+			INSERT INTO jobs (id, provider, tracking_code, status, created_at, updated_at)
+			VALUES ($1, $2, 'AA123', 'DONE', $3, $3)
+		`, uuid.New(), provider, at); err != nil {
+			t.Fatalf("insert job: %v", err)
+		}
+	}
+	t.Cleanup(func() { pool.Exec(context.Background(), `DELETE FROM jobs WHERE provider = $1`, provider) })
+
+	var listed []Job
+	f := JobFilter{Provider: provider, Limit: 2}
+	for pages := 0; ; pages++ {
+		if pages > len(createdAt) {
+			t.Fatalf("pagination did not end")
+		}
+		page, next, err := jobRepo.List(ctx, f)
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		listed = append(listed, page...)
+		if next == nil {
+			break
+		}
+		// Cursors go through their string form, as they do over the API.
+		after, err := DecodeJobCursor(next.Encode())
+		if err != nil {
+			t.Fatalf("decode cursor: %v", err)
+		}
+		f.After = &after
+	}
+
+	if len(listed) != len(createdAt) {
+		t.Fatalf("expected %d jobs, got %d", len(createdAt), len(listed))
+	}
+	seen := map[uuid.UUID]bool{}
+	for i, job := range listed {
+		if seen[job.ID] {
+			t.Fatalf("job %s listed twice", job.ID)
+		}
+		seen[job.ID] = true
+		if i == 0 {
+			continue
+		}
+		prev := listed[i-1]
+		if job.CreatedAt.After(prev.CreatedAt) ||
+			(job.CreatedAt.Equal(prev.CreatedAt) && job.ID.String() >= prev.ID.String()) {
+			t.Fatalf("jobs %s and %s out of order", prev.ID, job.ID)
+		}
+	}
+}
@@ -2,26 +2,35 @@ package repo
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Job struct {
-	ID           uuid.UUID `json:"job_id"`
-	Provider     string    `json:"provider"`
-	TrackingCode string    `json:"tracking_code"`
-	Status       string    `json:"status"`
-	Attempts     int       `json:"attempts"`
-	ErrorCode    *string   `json:"error_code,omitempty"`
-	ErrorMessage *string   `json:"error_message,omitempty"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID            uuid.UUID  `json:"job_id"`
+	Provider      string     `json:"provider"`
+	TrackingCode  string     `json:"tracking_code"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	ErrorCode     *string    `json:"error_code,omitempty"`
+	ErrorMessage  *string    `json:"error_message,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	ScheduledFor  *time.Time `json:"scheduled_for,omitempty"`
+	BatchID       *uuid.UUID `json:"batch_id,omitempty"`
+	// LockedBy and LeaseExpiresAt name the worker running the job and until
+	// when its lease holds; both are NULL outside RUNNING.
+	LockedBy       *string    `json:"locked_by,omitempty"`
+	LeaseExpiresAt *time.Time `json:"lease_expires_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
 }
 
+const jobColumns = `id, provider, tracking_code, status, attempts, error_code, error_message, next_attempt_at, scheduled_for, batch_id, locked_by, lease_expires_at, created_at, updated_at`
+
 type JobRepo struct {
 	pool *pgxpool.Pool
 }
@@ -30,11 +39,66 @@ func NewJobRepo(pool *pgxpool.Pool) *JobRepo {
 	return &JobRepo{pool: pool}
 }
 
-func (r *JobRepo) Create(ctx context.Context, job Job) error {
-	_, err := r.pool.Exec(ctx, `
-		INSERT INTO jobs (id, provider, tracking_code, status, attempts)
-		VALUES ($1, $2, $3, $4, $5)
-	`, job.ID, job.Provider, job.TrackingCode, job.Status, job.Attempts)
+// Create inserts the job. With an idempotency key, a live key seen before
+// returns the job it created and created=false instead; a live key reused for
+// a different request yields ErrIdempotencyMismatch.
+func (r *JobRepo) Create(ctx context.Context, job Job, key *IdempotencyKey) (Job, bool, error) {
+	return r.create(ctx, job, key, "", nil)
+}
+
+// CreateWithOutbox is Create plus the stream message that announces the job,
+// written in the same transaction; the outbox relay publishes it once
+// committed. Replays write no message.
+func (r *JobRepo) CreateWithOutbox(ctx context.Context, job Job, key *IdempotencyKey, stream string, values map[string]any) (Job, bool, error) {
+	return r.create(ctx, job, key, stream, values)
+}
+
+func (r *JobRepo) create(ctx context.Context, job Job, key *IdempotencyKey, stream string, values map[string]any) (Job, bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return Job{}, false, fmt.Errorf("begin create job: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := insertJob(ctx, tx, job); err != nil {
+		return Job{}, false, err
+	}
+	if key != nil {
+		claimed, err := claimIdempotencyKey(ctx, tx, *key, job.ID, SourceFresh)
+		if err != nil {
+			return Job{}, false, err
+		}
+		if !claimed {
+			// Drop the job inserted above and hand back the original.
+			if err := tx.Rollback(ctx); err != nil {
+				return Job{}, false, fmt.Errorf("rollback create job: %w", err)
+			}
+			replayed, err := r.replay(ctx, *key)
+			return replayed.Job, false, err
+		}
+	}
+	if values != nil {
+		if err := insertOutbox(ctx, tx, job.ID, stream, values); err != nil {
+			return Job{}, false, err
+		}
+	}
+
+	row := tx.QueryRow(ctx, `SELECT `+jobColumns+` FROM jobs WHERE id = $1`, job.ID)
+	created, err := scanJob(row)
+	if err != nil {
+		return Job{}, false, fmt.Errorf("get created job: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return Job{}, false, fmt.Errorf("commit create job: %w", err)
+	}
+	return created, true, nil
+}
+
+func insertJob(ctx context.Context, db execer, job Job) error {
+	_, err := db.Exec(ctx, `
+		INSERT INTO jobs (id, provider, tracking_code, status, attempts, scheduled_for, batch_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, job.ID, job.Provider, job.TrackingCode, job.Status, job.Attempts, job.ScheduledFor, job.BatchID)
 	if err != nil {
 		return fmt.Errorf("insert job: %w", err)
 	}
@@ -43,11 +107,15 @@ func (r *JobRepo) Create(ctx context.Context, job Job) error {
 
 func (r *JobRepo) Get(ctx context.Context, id uuid.UUID) (Job, error) {
 	row := r.pool.QueryRow(ctx, `
-		SELECT id, provider, tracking_code, status, attempts, error_code, error_message, created_at, updated_at
+		SELECT `+jobColumns+`
 		FROM jobs
 		WHERE id = $1
 	`, id)
 
+	return scanJob(row)
+}
+
+func scanJob(row pgx.Row) (Job, error) {
 	var job Job
 	if err := row.Scan(
 		&job.ID,
@@ -57,56 +125,77 @@ func (r *JobRepo) Get(ctx context.Context, id uuid.UUID) (Job, error) {
 		&job.Attempts,
 		&job.ErrorCode,
 		&job.ErrorMessage,
+		&job.NextAttemptAt,
+		&job.ScheduledFor,
+		&job.BatchID,
+		&job.LockedBy,
+		&job.LeaseExpiresAt,
 		&job.CreatedAt,
 		&job.UpdatedAt,
 	); err != nil {
 		return Job{}, err
 	}
-
 	return job, nil
 }
 
-func (r *JobRepo) MarkRunning(ctx context.Context, id uuid.UUID) error {
-	cmd, err := r.pool.Exec(ctx, `
-		UPDATE jobs
-		SET status = 'RUNNING', attempts = attempts + 1, updated_at = now()
-		WHERE id = $1
-	`, id)
-	if err != nil {
-		return fmt.Errorf("mark running: %w", err)
-	}
-	if cmd.RowsAffected() == 0 {
-		return sql.ErrNoRows
-	}
-	return nil
+// MarkRunning starts an attempt and leases the job to change.Actor for lease;
+// the worker keeps it with ExtendLease. A job already RUNNING is accepted
+// once its lease has expired, since its message may have been reclaimed from
+// a dead worker; while another worker's lease is live it returns
+// ErrLeaseLost and the delivery should be dropped.
+func (r *JobRepo) MarkRunning(ctx context.Context, id uuid.UUID, lease time.Duration, change Change) error {
+	now := time.Now().UTC()
+	_, _, err := r.transition(ctx, id, JobRunning, leaseFree(change.Actor, now), change,
+		`attempts = attempts + 1, locked_by = $3, lease_expires_at = $4,`, change.Actor, now.Add(lease))
+	return err
 }
 
-func (r *JobRepo) MarkDone(ctx context.Context, id uuid.UUID) error {
-	cmd, err := r.pool.Exec(ctx, `
-		UPDATE jobs
-		SET status = 'DONE', updated_at = now()
-		WHERE id = $1
-	`, id)
-	if err != nil {
-		return fmt.Errorf("mark done: %w", err)
+// MarkDone, MarkFailed and ScheduleRetry are for the worker holding the
+// lease: they return ErrLeaseLost unless the job is RUNNING under
+// change.Actor's lease.
+func (r *JobRepo) MarkDone(ctx context.Context, id uuid.UUID, change Change) error {
+	_, _, err := r.transition(ctx, id, JobDone, leasedTo(change.Actor), change, clearLease)
+	return err
+}
+
+func (r *JobRepo) MarkFailed(ctx context.Context, id uuid.UUID, code, message string, change Change) error {
+	if change.Reason == "" {
+		change.Reason = code
 	}
-	if cmd.RowsAffected() == 0 {
-		return sql.ErrNoRows
+	_, _, err := r.transition(ctx, id, JobFailed, leasedTo(change.Actor), change,
+		`error_code = $3, error_message = $4,`+clearLease, code, message)
+	return err
+}
+
+func (r *JobRepo) ScheduleRetry(ctx context.Context, id uuid.UUID, code, message string, at time.Time, change Change) error {
+	if change.Reason == "" {
+		change.Reason = code
 	}
-	return nil
+	_, _, err := r.transition(ctx, id, JobRetryScheduled, leasedTo(change.Actor), change,
+		`error_code = $3, error_message = $4, next_attempt_at = $5,`+clearLease, code, message, at)
+	return err
 }
 
-func (r *JobRepo) MarkFailed(ctx context.Context, id uuid.UUID, code, message string) error {
-	cmd, err := r.pool.Exec(ctx, `
-		UPDATE jobs
-		SET status = 'FAILED', error_code = $2, error_message = $3, updated_at = now()
-		WHERE id = $1
-	`, id, code, message)
+// RescheduleRetry puts a PENDING job whose message could not be enqueued back
+// to RETRY_SCHEDULED until at, keeping the error that caused the retry.
+func (r *JobRepo) RescheduleRetry(ctx context.Context, id uuid.UUID, at time.Time, change Change) error {
+	_, _, err := r.transition(ctx, id, JobRetryScheduled, inStatus(JobPending), change,
+		`next_attempt_at = $3,`, at)
+	return err
+}
+
+// ClaimDueRetries moves up to limit RETRY_SCHEDULED jobs whose next_attempt_at
+// has passed back to PENDING and returns them for re-enqueueing.
+func (r *JobRepo) ClaimDueRetries(ctx context.Context, now time.Time, limit int) ([]Job, error) {
+	jobs, err := r.transitionMany(ctx, JobPending, Change{Actor: ActorRetryDispatcher, Reason: "retry due"}, `
+		SELECT id, status FROM jobs
+		WHERE status = 'RETRY_SCHEDULED' AND next_attempt_at <= $1
+		ORDER BY next_attempt_at
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`, `next_attempt_at = NULL,`, now, limit)
 	if err != nil {
-		return fmt.Errorf("mark failed: %w", err)
+		return nil, fmt.Errorf("claim due retries: %w", err)
 	}
-	if cmd.RowsAffected() == 0 {
-		return sql.ErrNoRows
-	}
-	return nil
+	return jobs, nil
 }
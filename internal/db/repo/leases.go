@@ -0,0 +1,121 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrLeaseLost is returned when a worker acts on a job whose lease it no
+// longer holds: the reaper took the job back or another worker runs it now.
+var ErrLeaseLost = errors.New("job lease lost")
+
+const clearLease = ` locked_by = NULL, lease_expires_at = NULL,`
+
+var errLeaseActive = errors.New("job lease still active")
+
+// leasedTo rejects worker moves of a job that is not RUNNING under owner's
+// lease, for instance one the reaper already handed back as PENDING.
+func leasedTo(owner string) func(Job, string) error {
+	return func(job Job, _ string) error {
+		if job.Status != JobRunning || job.LockedBy == nil || *job.LockedBy != owner {
+			return fmt.Errorf("job %s is %s and not leased to %s: %w", job.ID, job.Status, owner, ErrLeaseLost)
+		}
+		return nil
+	}
+}
+
+// leaseFree rejects taking over a RUNNING job whose lease belongs to someone
+// other than owner and has not expired by now.
+func leaseFree(owner string, now time.Time) func(Job, string) error {
+	return func(job Job, _ string) error {
+		if job.Status != JobRunning || job.LockedBy == nil || *job.LockedBy == owner {
+			return nil
+		}
+		if job.LeaseExpiresAt != nil && job.LeaseExpiresAt.After(now) {
+			return fmt.Errorf("job %s is leased to %s: %w", job.ID, *job.LockedBy, ErrLeaseLost)
+		}
+		return nil
+	}
+}
+
+// ExtendLease pushes the lease of a RUNNING job held by owner to lease from
+// now and returns the new expiry.
+func (r *JobRepo) ExtendLease(ctx context.Context, id uuid.UUID, owner string, lease time.Duration) (time.Time, error) {
+	var expires time.Time
+	err := r.pool.QueryRow(ctx, `
+		UPDATE jobs
+		SET lease_expires_at = $3
+		WHERE id = $1 AND status = 'RUNNING' AND locked_by = $2
+		RETURNING lease_expires_at
+	`, id, owner, time.Now().UTC().Add(lease)).Scan(&expires)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, ErrLeaseLost
+		}
+		return time.Time{}, fmt.Errorf("extend lease: %w", err)
+	}
+	return expires, nil
+}
+
+// ExpiredLeases returns up to limit RUNNING jobs whose lease ran out before
+// now, oldest expiry first.
+func (r *JobRepo) ExpiredLeases(ctx context.Context, now time.Time, limit int) ([]Job, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+jobColumns+`
+		FROM jobs
+		WHERE status = 'RUNNING' AND lease_expires_at < $1
+		ORDER BY lease_expires_at
+		LIMIT $2
+	`, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list expired leases: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list expired leases: %w", err)
+	}
+	return jobs, nil
+}
+
+// ReleaseExpiredLease takes a job back from a worker whose lease ran out
+// before now: it goes to PENDING, or to FAILED with LEASE_EXPIRED when fail
+// is set. ok is false when the lease was renewed or the job moved on in the
+// meantime.
+func (r *JobRepo) ReleaseExpiredLease(ctx context.Context, id uuid.UUID, now time.Time, fail bool, change Change) (job Job, ok bool, err error) {
+	check := func(locked Job, _ string) error {
+		if locked.Status != JobRunning || locked.LeaseExpiresAt == nil || !locked.LeaseExpiresAt.Before(now) {
+			return errLeaseActive
+		}
+		return nil
+	}
+	if change.Reason == "" {
+		change.Reason = "LEASE_EXPIRED"
+	}
+	if fail {
+		job, _, err = r.transition(ctx, id, JobFailed, check, change,
+			`error_code = 'LEASE_EXPIRED', error_message = 'worker ' || COALESCE(locked_by, 'unknown') || ' stopped renewing its lease',`+clearLease)
+	} else {
+		job, _, err = r.transition(ctx, id, JobPending, check, change, clearLease)
+	}
+	if errors.Is(err, errLeaseActive) || errors.Is(err, ErrIllegalTransition) {
+		return Job{}, false, nil
+	}
+	if err != nil {
+		return Job{}, false, err
+	}
+	return job, true, nil
+}
@@ -0,0 +1,58 @@
+package repo
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestLeaseFree(t *testing.T) {
+	now := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	live := now.Add(time.Minute)
+	expired := now.Add(-time.Second)
+	owner := "worker-1"
+
+	cases := []struct {
+		name    string
+		actor   string
+		job     Job
+		refused bool
+	}{
+		{"pending", "worker-2", Job{Status: JobPending}, false},
+		{"live lease of another worker", "worker-2", Job{Status: JobRunning, LockedBy: &owner, LeaseExpiresAt: &live}, true},
+		{"expired lease of another worker", "worker-2", Job{Status: JobRunning, LockedBy: &owner, LeaseExpiresAt: &expired}, false},
+		{"own live lease", owner, Job{Status: JobRunning, LockedBy: &owner, LeaseExpiresAt: &live}, false},
+	}
+	for _, tc := range cases {
+		tc.job.ID = uuid.New()
+		err := leaseFree(tc.actor, now)(tc.job, tc.job.Status)
+		if refused := errors.Is(err, ErrLeaseLost); refused != tc.refused {
+			t.Errorf("%s: expected refused=%v, got %v", tc.name, tc.refused, err)
+		}
+	}
+}
+
+func TestLeasedTo(t *testing.T) {
+	owner := "worker-1"
+	other := "worker-2"
+
+	cases := []struct {
+		name    string
+		job     Job
+		refused bool
+	}{
+		{"running under own lease", Job{Status: JobRunning, LockedBy: &owner}, false},
+		{"running under another lease", Job{Status: JobRunning, LockedBy: &other}, true},
+		{"pending after the reaper took it back", Job{Status: JobPending}, true},
+		{"running without a lease", Job{Status: JobRunning}, true},
+	}
+	for _, tc := range cases {
+		tc.job.ID = uuid.New()
+		err := leasedTo(owner)(tc.job, JobFailed)
+		if refused := errors.Is(err, ErrLeaseLost); refused != tc.refused {
+			t.Errorf("%s: expected refused=%v, got %v", tc.name, tc.refused, err)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+type OutboxMessage struct {
+	ID        int64
+	JobID     *uuid.UUID
+	Stream    string
+	Values    map[string]any
+	Attempts  int
+	CreatedAt time.Time
+}
+
+type OutboxRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewOutboxRepo(pool *pgxpool.Pool) *OutboxRepo {
+	return &OutboxRepo{pool: pool}
+}
+
+func insertOutbox(ctx context.Context, db execer, jobID uuid.UUID, stream string, values map[string]any) error {
+	payload, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("marshal outbox payload: %w", err)
+	}
+	_, err = db.Exec(ctx, `
+		INSERT INTO outbox (job_id, stream, payload)
+		VALUES ($1, $2, $3)
+	`, jobID, stream, payload)
+	if err != nil {
+		return fmt.Errorf("insert outbox: %w", err)
+	}
+	return nil
+}
+
+// Relay locks up to limit unsent messages in id order and hands them to
+// publish, which returns the stream ids of the leading messages it sent.
+// Those are marked sent with their id; on failure the first unsent row records the error and it and the rest
+// are retried on the next call. A crash between publish and commit re-sends
+// the messages, so delivery is at-least-once.
+func (r *OutboxRepo) Relay(ctx context.Context, limit int, publish func(context.Context, []OutboxMessage) ([]string, error)) (int, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin relay: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		SELECT id, job_id, stream, payload, attempts, created_at
+		FROM outbox
+		WHERE sent_at IS NULL
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`, limit)
+	if err != nil {
+		return 0, fmt.Errorf("select outbox: %w", err)
+	}
+	var msgs []OutboxMessage
+	for rows.Next() {
+		var msg OutboxMessage
+		var payload []byte
+		if err := rows.Scan(&msg.ID, &msg.JobID, &msg.Stream, &payload, &msg.Attempts, &msg.CreatedAt); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan outbox: %w", err)
+		}
+		if err := json.Unmarshal(payload, &msg.Values); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("decode outbox %d: %w", msg.ID, err)
+		}
+		msgs = append(msgs, msg)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("select outbox: %w", err)
+	}
+
+	if len(msgs) == 0 {
+		return 0, nil
+	}
+	messageIDs, publishErr := publish(ctx, msgs)
+	sent := len(messageIDs)
+	if sent > 0 {
+		ids := make([]int64, sent)
+		for i := range ids {
+			ids[i] = msgs[i].ID
+		}
+		if _, err := tx.Exec(ctx, `
+			UPDATE outbox
+			SET sent_at = now(), attempts = attempts + 1, last_error = NULL, message_id = m.message_id
+			FROM unnest($1::bigint[], $2::text[]) AS m(id, message_id)
+			WHERE outbox.id = m.id
+		`, ids, messageIDs); err != nil {
+			return 0, fmt.Errorf("mark outbox sent: %w", err)
+		}
+	}
+	if publishErr != nil && sent < len(msgs) {
+		if _, err := tx.Exec(ctx, `
+			UPDATE outbox SET attempts = attempts + 1, last_error = $2 WHERE id = $1
+		`, msgs[sent].ID, publishErr.Error()); err != nil {
+			return 0, fmt.Errorf("record outbox error: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit relay: %w", err)
+	}
+	if publishErr != nil {
+		return sent, fmt.Errorf("publish outbox: %w", publishErr)
+	}
+	return sent, nil
+}
+
+// RequeueStale writes a fresh outbox message for up to limit PENDING jobs
+// that have not changed since before cutoff and have nothing waiting in the
+// outbox. Jobs scheduled for later are left alone until they are due, and so
+// are jobs whose last relayed message outstanding reports as still queued,
+// e.g. behind a backlog. Every claimed job's updated_at is bumped, skipped or
+// not, so it is not picked again until it goes stale once more.
+func (r *OutboxRepo) RequeueStale(ctx context.Context, cutoff time.Time, limit int, stream string, message func(Job) map[string]any, outstanding func(ctx context.Context, stream string, ids ...string) (map[string]bool, error)) ([]Job, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin requeue: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		UPDATE jobs
+		SET updated_at = now()
+		WHERE id IN (
+			SELECT j.id FROM jobs j
+			WHERE j.status = 'PENDING'
+				AND j.updated_at < $1
+				AND (j.scheduled_for IS NULL OR j.scheduled_for < $1)
+				AND NOT EXISTS (SELECT 1 FROM outbox o WHERE o.job_id = j.id AND o.sent_at IS NULL)
+			ORDER BY j.updated_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+jobColumns+`
+	`, cutoff, limit)
+	if err != nil {
+		return nil, fmt.Errorf("claim stale jobs: %w", err)
+	}
+	var jobs []Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("claim stale jobs: %w", err)
+	}
+
+	waiting, err := waitingJobs(ctx, tx, jobs, outstanding)
+	if err != nil {
+		return nil, err
+	}
+	requeued := jobs[:0]
+	for _, job := range jobs {
+		if waiting[job.ID] {
+			continue
+		}
+		if err := insertOutbox(ctx, tx, job.ID, stream, message(job)); err != nil {
+			return nil, err
+		}
+		requeued = append(requeued, job)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit requeue: %w", err)
+	}
+	return requeued, nil
+}
+
+// waitingJobs returns the jobs whose last message sent through the outbox is
+// still outstanding. Jobs enqueued around the outbox have no recorded id and
+// never count as waiting.
+func waitingJobs(ctx context.Context, tx pgx.Tx, jobs []Job, outstanding func(ctx context.Context, stream string, ids ...string) (map[string]bool, error)) (map[uuid.UUID]bool, error) {
+	waiting := make(map[uuid.UUID]bool)
+	if len(jobs) == 0 || outstanding == nil {
+		return waiting, nil
+	}
+	jobIDs := make([]uuid.UUID, len(jobs))
+	for i, job := range jobs {
+		jobIDs[i] = job.ID
+	}
+	rows, err := tx.Query(ctx, `
+		SELECT DISTINCT ON (job_id) job_id, stream, message_id
+		FROM outbox
+		WHERE job_id = ANY($1) AND sent_at IS NOT NULL
+		ORDER BY job_id, id DESC
+	`, jobIDs)
+	if err != nil {
+		return nil, fmt.Errorf("select sent messages: %w", err)
+	}
+	byStream := make(map[string]map[string]uuid.UUID)
+	for rows.Next() {
+		var jobID uuid.UUID
+		var stream string
+		var messageID *string
+		if err := rows.Scan(&jobID, &stream, &messageID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan sent message: %w", err)
+		}
+		if messageID == nil {
+			continue
+		}
+		if byStream[stream] == nil {
+			byStream[stream] = make(map[string]uuid.UUID)
+		}
+		byStream[stream][*messageID] = jobID
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select sent messages: %w", err)
+	}
+
+	for stream, jobsByMessage := range byStream {
+		ids := make([]string, 0, len(jobsByMessage))
+		for id := range jobsByMessage {
+			ids = append(ids, id)
+		}
+		found, err := outstanding(ctx, stream, ids...)
+		if err != nil {
+			return nil, fmt.Errorf("check outstanding messages: %w", err)
+		}
+		for id := range found {
+			waiting[jobsByMessage[id]] = true
+		}
+	}
+	return waiting, nil
+}
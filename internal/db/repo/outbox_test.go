@@ -0,0 +1,66 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRequeueStaleSkipsOutstandingMessages(t *testing.T) {
+	pool := testPool(t)
+	outbox := NewOutboxRepo(pool)
+	ctx := context.Background()
+
+	provider := "requeue-test-" + uuid.NewString()
+	stream := "test:requeue:" + uuid.NewString()
+	stale := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	queued, lost := uuid.New(), uuid.New()
+	for id, messageID := range map[uuid.UUID]string{queued: "1-0", lost: "2-0"} {
+		if _, err := pool.Exec(ctx, `
+			INSERT INTO jobs (id, provider, tracking_code, status, created_at, updated_at)
+			VALUES ($1, $2, 'AA123', 'PENDING', $3, $3)
+		`, id, provider, stale); err != nil {
+			t.Fatalf("insert job: %v", err)
+		}
+		if _, err := pool.Exec(ctx, `
+			INSERT INTO outbox (job_id, stream, payload, sent_at, message_id)
+			VALUES ($1, $2, '{}', $3, $4)
+		`, id, stream, stale, messageID); err != nil {
+			t.Fatalf("insert outbox: %v", err)
+		}
+	}
+	t.Cleanup(func() { pool.Exec(context.Background(), `DELETE FROM jobs WHERE provider = $1`, provider) })
+
+	requeued, err := outbox.RequeueStale(ctx, stale.Add(time.Hour), 100, stream,
+		func(job Job) map[string]any { return map[string]any{"job_id": job.ID.String()} },
+		func(ctx context.Context, s string, ids ...string) (map[string]bool, error) {
+			if s != stream {
+				return map[string]bool{}, nil
+			}
+			return map[string]bool{"1-0": true}, nil
+		})
+	if err != nil {
+		t.Fatalf("requeue stale: %v", err)
+	}
+
+	var ours []uuid.UUID
+	for _, job := range requeued {
+		if job.Provider == provider {
+			ours = append(ours, job.ID)
+		}
+	}
+	if len(ours) != 1 || ours[0] != lost {
+		t.Fatalf("expected only %s requeued, got %v", lost, ours)
+	}
+	var unsent int
+	if err := pool.QueryRow(ctx, `
+		SELECT count(*) FROM outbox WHERE job_id = $1 AND sent_at IS NULL
+	`, queued).Scan(&unsent); err != nil {
+		t.Fatalf("count outbox: %v", err)
+	}
+	if unsent != 0 {
+		t.Fatalf("expected no new outbox row for the queued job, got %d", unsent)
+	}
+}
@@ -0,0 +1,60 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotRetryable is returned by Requeue for jobs that have not finished.
+var ErrNotRetryable = errors.New("job is not in a terminal state")
+
+// requeueSet resets attempts so the re-run gets a full retry budget; earlier
+// runs stay in job_attempts and job_transitions.
+const requeueSet = `attempts = 0, error_code = NULL, error_message = NULL, next_attempt_at = NULL,`
+
+// Requeue puts a DONE, FAILED or CANCELLED job back to PENDING under the same
+// id, with a fresh retry budget.
+func (r *JobRepo) Requeue(ctx context.Context, id uuid.UUID, change Change) (Job, error) {
+	job, _, err := r.transition(ctx, id, JobPending, inStatus(JobDone, JobFailed, JobCancelled), change, requeueSet)
+	if errors.Is(err, ErrIllegalTransition) {
+		return Job{}, fmt.Errorf("%w: %w", ErrNotRetryable, err)
+	}
+	return job, err
+}
+
+// RequeueMatching requeues up to f.Limit terminal jobs matching f, oldest
+// first. Without Statuses it only picks FAILED jobs; non-terminal statuses in
+// the filter match nothing.
+func (r *JobRepo) RequeueMatching(ctx context.Context, f JobFilter, change Change) ([]Job, error) {
+	limit := f.Limit
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	if limit > MaxListLimit {
+		limit = MaxListLimit
+	}
+	if len(f.Statuses) == 0 {
+		f.Statuses = []string{JobFailed}
+	}
+	f.After = nil
+
+	where, args := f.conditions()
+	where = append(where, "status IN ('DONE', 'FAILED', 'CANCELLED')")
+	args = append(args, limit)
+
+	jobs, err := r.transitionMany(ctx, JobPending, change, fmt.Sprintf(`
+		SELECT id, status FROM jobs
+		WHERE %s
+		ORDER BY created_at, id
+		LIMIT $%d
+		FOR UPDATE SKIP LOCKED
+	`, strings.Join(where, " AND "), len(args)), requeueSet, args...)
+	if err != nil {
+		return nil, fmt.Errorf("requeue jobs: %w", err)
+	}
+	return jobs, nil
+}
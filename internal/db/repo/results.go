@@ -9,6 +9,8 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"logisync/internal/providers"
 )
 
 type ResultRepo struct {
@@ -16,8 +18,11 @@ type ResultRepo struct {
 }
 
 type TrackingResult struct {
+	JobID             uuid.UUID       `json:"job_id"`
 	Provider          string          `json:"provider"`
 	TrackingCode      string          `json:"tracking_code"`
+	Status            string          `json:"status"`
+	RawStatus         string          `json:"raw_status"`
 	NormalizedPayload json.RawMessage `json:"normalized_payload"`
 	CreatedAt         string          `json:"created_at"`
 }
@@ -26,39 +31,60 @@ func NewResultRepo(pool *pgxpool.Pool) *ResultRepo {
 	return &ResultRepo{pool: pool}
 }
 
-func (r *ResultRepo) Insert(ctx context.Context, jobID uuid.UUID, provider, trackingCode string, payload any) error {
-	payloadJSON, err := json.Marshal(payload)
+func (r *ResultRepo) Insert(ctx context.Context, jobID uuid.UUID, provider, trackingCode string, shipment providers.Shipment) error {
+	if err := shipment.Validate(); err != nil {
+		return err
+	}
+	payloadJSON, err := json.Marshal(shipment)
 	if err != nil {
 		return fmt.Errorf("marshal payload: %w", err)
 	}
 
 	_, err = r.pool.Exec(ctx, `
-		INSERT INTO tracking_results (id, job_id, provider, tracking_code, normalized_payload)
-		VALUES (gen_random_uuid(), $1, $2, $3, $4)
-	`, jobID, provider, trackingCode, payloadJSON)
+		INSERT INTO tracking_results (id, job_id, provider, tracking_code, normalized_payload, status, raw_status)
+		VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6)
+	`, jobID, provider, trackingCode, payloadJSON, string(shipment.Status), shipment.RawStatus)
 	if err != nil {
 		return fmt.Errorf("insert tracking result: %w", err)
 	}
 	return nil
 }
 
+const resultColumns = `job_id, provider, tracking_code, COALESCE(status, ''), COALESCE(raw_status, ''), normalized_payload, created_at`
+
 func (r *ResultRepo) GetLatestByJobID(ctx context.Context, jobID uuid.UUID) (TrackingResult, error) {
 	row := r.pool.QueryRow(ctx, `
-		SELECT provider, tracking_code, normalized_payload, created_at
+		SELECT `+resultColumns+`
 		FROM tracking_results
 		WHERE job_id = $1
 		ORDER BY created_at DESC
 		LIMIT 1
 	`, jobID)
 
-	var result TrackingResult
-	var createdAt time.Time
-	if err := row.Scan(&result.Provider, &result.TrackingCode, &result.NormalizedPayload, &createdAt); err != nil {
+	result, err := scanResult(row)
+	if err != nil {
 		if err == pgx.ErrNoRows {
 			return TrackingResult{}, err
 		}
 		return TrackingResult{}, fmt.Errorf("get latest result: %w", err)
 	}
+	return result, nil
+}
+
+func scanResult(row pgx.Row) (TrackingResult, error) {
+	var result TrackingResult
+	var createdAt time.Time
+	if err := row.Scan(
+		&result.JobID,
+		&result.Provider,
+		&result.TrackingCode,
+		&result.Status,
+		&result.RawStatus,
+		&result.NormalizedPayload,
+		&createdAt,
+	); err != nil {
+		return TrackingResult{}, err
+	}
 	result.CreatedAt = createdAt.Format(time.RFC3339)
 	return result, nil
 }
@@ -0,0 +1,173 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"logisync/internal/providers"
+)
+
+type ShipmentRecord struct {
+	ID           uuid.UUID  `json:"shipment_id"`
+	Provider     string     `json:"provider"`
+	TrackingCode string     `json:"tracking_code"`
+	Status       string     `json:"status"`
+	RawStatus    *string    `json:"raw_status,omitempty"`
+	LastUpdate   *time.Time `json:"last_update,omitempty"`
+	LastJobID    *uuid.UUID `json:"last_job_id,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+type TimelineEvent struct {
+	providers.TrackingEvent
+	FirstSeenAt    time.Time  `json:"first_seen_at"`
+	FirstSeenJobID *uuid.UUID `json:"first_seen_job_id,omitempty"`
+}
+
+type Timeline struct {
+	Shipment ShipmentRecord  `json:"shipment"`
+	Events   []TimelineEvent `json:"events"`
+}
+
+type MergeResult struct {
+	ShipmentID     uuid.UUID
+	PreviousStatus string
+	Status         string
+	NewEvents      []providers.TrackingEvent
+}
+
+func (m MergeResult) StatusChanged() bool {
+	return m.PreviousStatus != "" && m.PreviousStatus != m.Status
+}
+
+type ShipmentRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewShipmentRepo(pool *pgxpool.Pool) *ShipmentRepo {
+	return &ShipmentRepo{pool: pool}
+}
+
+// Merge folds a tracking result into the shipment's timeline. Events already
+// seen are skipped by their key; the rest are returned as NewEvents. The
+// shipment status only moves forward in last_update order.
+func (r *ShipmentRepo) Merge(ctx context.Context, jobID uuid.UUID, shipment providers.Shipment) (MergeResult, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return MergeResult{}, fmt.Errorf("begin merge: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var result MergeResult
+	err = tx.QueryRow(ctx, `
+		SELECT id, status FROM shipments
+		WHERE provider = $1 AND tracking_code = $2
+		FOR UPDATE
+	`, shipment.Provider, shipment.TrackingCode).Scan(&result.ShipmentID, &result.PreviousStatus)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		err = tx.QueryRow(ctx, `
+			INSERT INTO shipments (id, provider, tracking_code, status, raw_status, last_update, last_job_id)
+			VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6)
+			ON CONFLICT (provider, tracking_code) DO UPDATE SET updated_at = now()
+			RETURNING id
+		`, shipment.Provider, shipment.TrackingCode, string(shipment.Status), shipment.RawStatus, shipment.LastUpdate, jobID).Scan(&result.ShipmentID)
+		if err != nil {
+			return MergeResult{}, fmt.Errorf("insert shipment: %w", err)
+		}
+		result.Status = string(shipment.Status)
+	case err != nil:
+		return MergeResult{}, fmt.Errorf("lock shipment: %w", err)
+	default:
+		// A result older than the one already merged, such as a retried lookup
+		// finishing late, still adds its events but leaves the status alone.
+		cmd, err := tx.Exec(ctx, `
+			UPDATE shipments
+			SET status = $2, raw_status = $3, last_update = $4, last_job_id = $5, updated_at = now()
+			WHERE id = $1 AND (last_update IS NULL OR last_update <= $4)
+		`, result.ShipmentID, string(shipment.Status), shipment.RawStatus, shipment.LastUpdate, jobID)
+		if err != nil {
+			return MergeResult{}, fmt.Errorf("update shipment: %w", err)
+		}
+		result.Status = result.PreviousStatus
+		if cmd.RowsAffected() > 0 {
+			result.Status = string(shipment.Status)
+		}
+	}
+
+	for _, evt := range shipment.Events {
+		cmd, err := tx.Exec(ctx, `
+			INSERT INTO shipment_events (id, shipment_id, event_key, occurred_at, description, city, state, country,
+				status, raw_status, event_code, first_seen_job_id)
+			VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			ON CONFLICT (shipment_id, event_key) DO NOTHING
+		`, result.ShipmentID, evt.Key(), evt.Timestamp, evt.Description, evt.Location.City, evt.Location.State,
+			evt.Location.Country, string(evt.Status), evt.RawStatus, evt.EventCode, jobID)
+		if err != nil {
+			return MergeResult{}, fmt.Errorf("insert shipment event: %w", err)
+		}
+		if cmd.RowsAffected() > 0 {
+			result.NewEvents = append(result.NewEvents, evt)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return MergeResult{}, fmt.Errorf("commit merge: %w", err)
+	}
+	return result, nil
+}
+
+func (r *ShipmentRepo) Timeline(ctx context.Context, provider, trackingCode string) (Timeline, error) {
+	var timeline Timeline
+	s := &timeline.Shipment
+	err := r.pool.QueryRow(ctx, `
+		SELECT id, provider, tracking_code, status, raw_status, last_update, last_job_id, created_at, updated_at
+		FROM shipments
+		WHERE provider = $1 AND tracking_code = $2
+	`, provider, trackingCode).Scan(
+		&s.ID, &s.Provider, &s.TrackingCode, &s.Status, &s.RawStatus, &s.LastUpdate, &s.LastJobID, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Timeline{}, err
+		}
+		return Timeline{}, fmt.Errorf("get shipment: %w", err)
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT occurred_at, description, COALESCE(city, ''), COALESCE(state, ''), COALESCE(country, ''),
+			COALESCE(status, ''), COALESCE(raw_status, ''), COALESCE(event_code, ''), first_seen_at, first_seen_job_id
+		FROM shipment_events
+		WHERE shipment_id = $1
+		ORDER BY occurred_at, first_seen_at
+	`, s.ID)
+	if err != nil {
+		return Timeline{}, fmt.Errorf("list shipment events: %w", err)
+	}
+	defer rows.Close()
+
+	timeline.Events = []TimelineEvent{}
+	for rows.Next() {
+		var evt TimelineEvent
+		var status string
+		if err := rows.Scan(
+			&evt.Timestamp, &evt.Description, &evt.Location.City, &evt.Location.State, &evt.Location.Country,
+			&status, &evt.RawStatus, &evt.EventCode, &evt.FirstSeenAt, &evt.FirstSeenJobID,
+		); err != nil {
+			return Timeline{}, fmt.Errorf("scan shipment event: %w", err)
+		}
+		evt.Status = providers.ShipmentStatus(status)
+		timeline.Events = append(timeline.Events, evt)
+	}
+	if err := rows.Err(); err != nil {
+		return Timeline{}, fmt.Errorf("list shipment events: %w", err)
+	}
+	return timeline, nil
+}
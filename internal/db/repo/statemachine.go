@@ -0,0 +1,247 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	JobPending        = "PENDING"
+	JobRunning        = "RUNNING"
+	JobRetryScheduled = "RETRY_SCHEDULED"
+	JobDone           = "DONE"
+	JobFailed         = "FAILED"
+	JobCancelled      = "CANCELLED"
+)
+
+// Actors used by background processes; workers use their consumer name and
+// the API the caller it authenticated.
+const (
+	ActorRetryDispatcher = "retry-dispatcher"
+	ActorLeaseReaper     = "lease-reaper"
+)
+
+// transitions lists the statuses each status may move to. RUNNING -> RUNNING
+// covers a message redelivered after its worker died, RUNNING -> PENDING a
+// job whose lease expired, and PENDING -> FAILED a message given up on before
+// it ran.
+var transitions = map[string][]string{
+	JobPending:        {JobRunning, JobRetryScheduled, JobFailed, JobCancelled},
+	JobRunning:        {JobRunning, JobPending, JobDone, JobFailed, JobRetryScheduled, JobCancelled},
+	JobRetryScheduled: {JobPending, JobCancelled},
+	JobDone:           {JobPending},
+	JobFailed:         {JobPending},
+	JobCancelled:      {JobPending},
+}
+
+func CanTransition(from, to string) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+var ErrIllegalTransition = errors.New("illegal job transition")
+
+type TransitionError struct {
+	JobID uuid.UUID
+	From  string
+	To    string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("job %s: illegal transition %s -> %s", e.JobID, e.From, e.To)
+}
+
+// Is matches ErrIllegalTransition, and ErrJobCancelled when the job had been
+// cancelled.
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrIllegalTransition || (target == ErrJobCancelled && e.From == JobCancelled)
+}
+
+// Change says who moved a job and why; it is stored in job_transitions.
+type Change struct {
+	Actor  string
+	Reason string
+}
+
+type JobTransition struct {
+	ID        int64     `json:"id"`
+	JobID     uuid.UUID `json:"job_id"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Actor     string    `json:"actor"`
+	Reason    *string   `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// transition moves job id to status to if the move is legal and check, when
+// not nil, accepts the locked job. set holds extra assignments for the UPDATE,
+// each ending in a comma, using args as $3 onwards. It returns the updated job
+// and the status it came from.
+func (r *JobRepo) transition(ctx context.Context, id uuid.UUID, to string, check func(Job, string) error, change Change, set string, args ...any) (Job, string, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return Job{}, "", fmt.Errorf("begin transition: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	current, err := scanJob(tx.QueryRow(ctx, `SELECT `+jobColumns+` FROM jobs WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Job{}, "", sql.ErrNoRows
+		}
+		return Job{}, "", fmt.Errorf("lock job: %w", err)
+	}
+	if !CanTransition(current.Status, to) {
+		return Job{}, current.Status, &TransitionError{JobID: id, From: current.Status, To: to}
+	}
+	if check != nil {
+		if err := check(current, to); err != nil {
+			return Job{}, current.Status, err
+		}
+	}
+
+	job, err := scanJob(tx.QueryRow(ctx, `
+		UPDATE jobs
+		SET status = $2, `+set+` updated_at = now()
+		WHERE id = $1
+		RETURNING `+jobColumns, append([]any{id, to}, args...)...))
+	if err != nil {
+		return Job{}, current.Status, fmt.Errorf("update job status: %w", err)
+	}
+	if err := insertTransitions(ctx, tx, []uuid.UUID{id}, []string{current.Status}, to, change); err != nil {
+		return Job{}, current.Status, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return Job{}, current.Status, fmt.Errorf("commit transition: %w", err)
+	}
+	return job, current.Status, nil
+}
+
+// inStatus restricts a transition to jobs currently in one of statuses.
+func inStatus(statuses ...string) func(Job, string) error {
+	return func(job Job, to string) error {
+		if !contains(statuses, job.Status) {
+			return &TransitionError{JobID: job.ID, From: job.Status, To: to}
+		}
+		return nil
+	}
+}
+
+// transitionMany applies a set-based move. selectOld must select id and
+// status of the jobs to move, locking them; its placeholders start at $1 and
+// set's follow them. Callers make sure selectOld only picks statuses from
+// which the move to to is legal.
+func (r *JobRepo) transitionMany(ctx context.Context, to string, change Change, selectOld, set string, args ...any) ([]Job, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin transition: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	args = append(args, to)
+	rows, err := tx.Query(ctx, fmt.Sprintf(`
+		UPDATE jobs j
+		SET status = $%d, %s updated_at = now()
+		FROM (%s) old
+		WHERE j.id = old.id
+		RETURNING old.status, %s
+	`, len(args), set, selectOld, qualify("j", jobColumns)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("update job status: %w", err)
+	}
+
+	var jobs []Job
+	var ids []uuid.UUID
+	var froms []string
+	for rows.Next() {
+		var job Job
+		var from string
+		if err := rows.Scan(
+			&from, &job.ID, &job.Provider, &job.TrackingCode, &job.Status, &job.Attempts, &job.ErrorCode,
+			&job.ErrorMessage, &job.NextAttemptAt, &job.ScheduledFor, &job.BatchID, &job.LockedBy,
+			&job.LeaseExpiresAt, &job.CreatedAt, &job.UpdatedAt,
+		); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan job: %w", err)
+		}
+		jobs = append(jobs, job)
+		ids = append(ids, job.ID)
+		froms = append(froms, from)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("update job status: %w", err)
+	}
+
+	if len(ids) > 0 {
+		if err := insertTransitions(ctx, tx, ids, froms, to, change); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit transition: %w", err)
+	}
+	return jobs, nil
+}
+
+func insertTransitions(ctx context.Context, tx pgx.Tx, ids []uuid.UUID, froms []string, to string, change Change) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO job_transitions (job_id, from_status, to_status, actor, reason)
+		SELECT id, from_status, $3, $4, NULLIF($5, '')
+		FROM unnest($1::uuid[], $2::text[]) AS t(id, from_status)
+	`, ids, froms, to, change.Actor, change.Reason)
+	if err != nil {
+		return fmt.Errorf("insert job transition: %w", err)
+	}
+	return nil
+}
+
+func (r *JobRepo) ListTransitions(ctx context.Context, jobID uuid.UUID) ([]JobTransition, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, job_id, from_status, to_status, actor, reason, created_at
+		FROM job_transitions
+		WHERE job_id = $1
+		ORDER BY id
+	`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("list job transitions: %w", err)
+	}
+	defer rows.Close()
+
+	history := []JobTransition{}
+	for rows.Next() {
+		var t JobTransition
+		if err := rows.Scan(&t.ID, &t.JobID, &t.From, &t.To, &t.Actor, &t.Reason, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan job transition: %w", err)
+		}
+		history = append(history, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list job transitions: %w", err)
+	}
+	return history, nil
+}
+
+func qualify(alias, columns string) string {
+	return alias + "." + strings.ReplaceAll(columns, ", ", ", "+alias+".")
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package repo
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCanTransition(t *testing.T) {
+	cases := []struct {
+		from, to string
+		want     bool
+	}{
+		{JobPending, JobRunning, true},
+		{JobRunning, JobRunning, true},
+		{JobRunning, JobDone, true},
+		{JobRunning, JobRetryScheduled, true},
+		{JobRunning, JobPending, true},
+		{JobRetryScheduled, JobPending, true},
+		{JobFailed, JobPending, true},
+		{JobRunning, JobCancelled, true},
+		{JobDone, JobRunning, false},
+		{JobDone, JobFailed, false},
+		{JobFailed, JobRunning, false},
+		{JobCancelled, JobDone, false},
+		{JobCancelled, JobCancelled, false},
+		{JobRetryScheduled, JobRunning, false},
+		{"UNKNOWN", JobPending, false},
+	}
+	for _, tc := range cases {
+		if got := CanTransition(tc.from, tc.to); got != tc.want {
+			t.Errorf("CanTransition(%s, %s) = %v, want %v", tc.from, tc.to, got, tc.want)
+		}
+	}
+}
+
+func TestTransitionErrorIs(t *testing.T) {
+	err := error(&TransitionError{JobID: uuid.New(), From: JobDone, To: JobRunning})
+	if !errors.Is(err, ErrIllegalTransition) {
+		t.Fatal("expected ErrIllegalTransition")
+	}
+	if errors.Is(err, ErrJobCancelled) {
+		t.Fatal("DONE job reported as cancelled")
+	}
+
+	err = error(&TransitionError{JobID: uuid.New(), From: JobCancelled, To: JobDone})
+	if !errors.Is(err, ErrJobCancelled) {
+		t.Fatal("expected ErrJobCancelled")
+	}
+}
@@ -0,0 +1,71 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"logisync/internal/providers"
+)
+
+type UnmappedStatus struct {
+	Provider      string    `json:"provider"`
+	RawStatus     string    `json:"raw_status"`
+	GuessedStatus string    `json:"guessed_status"`
+	Occurrences   int       `json:"occurrences"`
+	FirstSeenAt   time.Time `json:"first_seen_at"`
+	LastSeenAt    time.Time `json:"last_seen_at"`
+}
+
+// StatusRepo stores carrier status phrases that no mapping table knew, so
+// they can be reviewed and added to the provider's StatusTable.
+type StatusRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewStatusRepo(pool *pgxpool.Pool) *StatusRepo {
+	return &StatusRepo{pool: pool}
+}
+
+func (r *StatusRepo) RecordUnmapped(ctx context.Context, provider, rawStatus string, guess providers.ShipmentStatus) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO unmapped_statuses (provider, raw_status, guessed_status)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (provider, raw_status) DO UPDATE
+		SET occurrences = unmapped_statuses.occurrences + 1,
+			guessed_status = EXCLUDED.guessed_status,
+			last_seen_at = now()
+	`, provider, rawStatus, string(guess))
+	if err != nil {
+		return fmt.Errorf("record unmapped status: %w", err)
+	}
+	return nil
+}
+
+func (r *StatusRepo) ListUnmapped(ctx context.Context, provider string) ([]UnmappedStatus, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT provider, raw_status, guessed_status, occurrences, first_seen_at, last_seen_at
+		FROM unmapped_statuses
+		WHERE $1 = '' OR provider = $1
+		ORDER BY occurrences DESC, last_seen_at DESC
+	`, provider)
+	if err != nil {
+		return nil, fmt.Errorf("list unmapped statuses: %w", err)
+	}
+	defer rows.Close()
+
+	var statuses []UnmappedStatus
+	for rows.Next() {
+		var s UnmappedStatus
+		if err := rows.Scan(&s.Provider, &s.RawStatus, &s.GuessedStatus, &s.Occurrences, &s.FirstSeenAt, &s.LastSeenAt); err != nil {
+			return nil, fmt.Errorf("scan unmapped status: %w", err)
+		}
+		statuses = append(statuses, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list unmapped statuses: %w", err)
+	}
+	return statuses, nil
+}
@@ -0,0 +1,179 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Subscription struct {
+	ID                     uuid.UUID  `json:"subscription_id"`
+	Provider               string     `json:"provider"`
+	TrackingCode           string     `json:"tracking_code"`
+	Status                 string     `json:"status"`
+	StopReason             *string    `json:"stop_reason,omitempty"`
+	IntervalSeconds        int        `json:"interval_seconds"`
+	CurrentIntervalSeconds int        `json:"current_interval_seconds"`
+	MaxIntervalSeconds     int        `json:"max_interval_seconds"`
+	MaxPolls               int        `json:"max_polls"`
+	Polls                  int        `json:"polls"`
+	TerminalStatuses       []string   `json:"terminal_statuses"`
+	ExpiresAt              *time.Time `json:"expires_at,omitempty"`
+	LastStatus             *string    `json:"last_status,omitempty"`
+	LastJobID              *uuid.UUID `json:"last_job_id,omitempty"`
+	NextPollAt             time.Time  `json:"next_poll_at"`
+	CreatedAt              time.Time  `json:"created_at"`
+	UpdatedAt              time.Time  `json:"updated_at"`
+}
+
+const subscriptionColumns = `id, provider, tracking_code, status, stop_reason, interval_seconds, current_interval_seconds,
+	max_interval_seconds, max_polls, polls, terminal_statuses, expires_at, last_status, last_job_id, next_poll_at,
+	created_at, updated_at`
+
+type SubscriptionRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewSubscriptionRepo(pool *pgxpool.Pool) *SubscriptionRepo {
+	return &SubscriptionRepo{pool: pool}
+}
+
+func (r *SubscriptionRepo) Create(ctx context.Context, sub Subscription) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO subscriptions (id, provider, tracking_code, status, interval_seconds, current_interval_seconds,
+			max_interval_seconds, max_polls, terminal_statuses, expires_at, next_poll_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, sub.ID, sub.Provider, sub.TrackingCode, sub.Status, sub.IntervalSeconds, sub.CurrentIntervalSeconds,
+		sub.MaxIntervalSeconds, sub.MaxPolls, sub.TerminalStatuses, sub.ExpiresAt, sub.NextPollAt)
+	if err != nil {
+		return fmt.Errorf("insert subscription: %w", err)
+	}
+	return nil
+}
+
+func (r *SubscriptionRepo) Get(ctx context.Context, id uuid.UUID) (Subscription, error) {
+	row := r.pool.QueryRow(ctx, `
+		SELECT `+subscriptionColumns+`
+		FROM subscriptions
+		WHERE id = $1
+	`, id)
+	return scanSubscription(row)
+}
+
+// ClaimDue returns up to limit ACTIVE subscriptions due at now and pushes
+// their next_poll_at forward by lease so other pollers skip them meanwhile.
+func (r *SubscriptionRepo) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Subscription, error) {
+	rows, err := r.pool.Query(ctx, `
+		UPDATE subscriptions
+		SET next_poll_at = $1::timestamptz + $2 * interval '1 second', updated_at = now()
+		WHERE id IN (
+			SELECT id FROM subscriptions
+			WHERE status = 'ACTIVE' AND next_poll_at <= $1
+			ORDER BY next_poll_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+subscriptionColumns+`
+	`, now, lease.Seconds(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("claim due subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subs []Subscription
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan subscription: %w", err)
+		}
+		subs = append(subs, sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("claim due subscriptions: %w", err)
+	}
+	return subs, nil
+}
+
+// RecordPoll stores the job spawned for this poll and when to poll next.
+func (r *SubscriptionRepo) RecordPoll(ctx context.Context, id, jobID uuid.UUID, lastStatus *string, intervalSeconds int, nextPollAt time.Time) error {
+	cmd, err := r.pool.Exec(ctx, `
+		UPDATE subscriptions
+		SET polls = polls + 1, last_job_id = $2, last_status = $3, current_interval_seconds = $4,
+			next_poll_at = $5, updated_at = now()
+		WHERE id = $1
+	`, id, jobID, lastStatus, intervalSeconds, nextPollAt)
+	if err != nil {
+		return fmt.Errorf("record poll: %w", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *SubscriptionRepo) Reschedule(ctx context.Context, id uuid.UUID, lastStatus *string, intervalSeconds int, nextPollAt time.Time) error {
+	cmd, err := r.pool.Exec(ctx, `
+		UPDATE subscriptions
+		SET last_status = $2, current_interval_seconds = $3, next_poll_at = $4, updated_at = now()
+		WHERE id = $1
+	`, id, lastStatus, intervalSeconds, nextPollAt)
+	if err != nil {
+		return fmt.Errorf("reschedule subscription: %w", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *SubscriptionRepo) Stop(ctx context.Context, id uuid.UUID, status, reason string, lastStatus *string) error {
+	cmd, err := r.pool.Exec(ctx, `
+		UPDATE subscriptions
+		SET status = $2, stop_reason = $3, last_status = COALESCE($4, last_status), updated_at = now()
+		WHERE id = $1 AND status = 'ACTIVE'
+	`, id, status, reason, lastStatus)
+	if err != nil {
+		return fmt.Errorf("stop subscription: %w", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Cancel stops an ACTIVE subscription on request. Like Stop, it returns
+// sql.ErrNoRows for unknown or already stopped subscriptions.
+func (r *SubscriptionRepo) Cancel(ctx context.Context, id uuid.UUID) error {
+	return r.Stop(ctx, id, "CANCELLED", "cancelled", nil)
+}
+
+func scanSubscription(row pgx.Row) (Subscription, error) {
+	var sub Subscription
+	if err := row.Scan(
+		&sub.ID,
+		&sub.Provider,
+		&sub.TrackingCode,
+		&sub.Status,
+		&sub.StopReason,
+		&sub.IntervalSeconds,
+		&sub.CurrentIntervalSeconds,
+		&sub.MaxIntervalSeconds,
+		&sub.MaxPolls,
+		&sub.Polls,
+		&sub.TerminalStatuses,
+		&sub.ExpiresAt,
+		&sub.LastStatus,
+		&sub.LastJobID,
+		&sub.NextPollAt,
+		&sub.CreatedAt,
+		&sub.UpdatedAt,
+	); err != nil {
+		return Subscription{}, err
+	}
+	return sub, nil
+}
@@ -0,0 +1,264 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type WebhookEndpoint struct {
+	ID        uuid.UUID `json:"endpoint_id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"-"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type WebhookDelivery struct {
+	ID            uuid.UUID       `json:"delivery_id"`
+	EndpointID    uuid.UUID       `json:"endpoint_id"`
+	EventID       uuid.UUID       `json:"event_id"`
+	EventType     string          `json:"event_type"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	ResponseCode  *int            `json:"response_code,omitempty"`
+	ResponseBody  *string         `json:"response_body,omitempty"`
+	ErrorMessage  *string         `json:"error_message,omitempty"`
+	DeliveredAt   *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+
+	// Populated by ClaimDueDeliveries.
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+// DeliveryAttempt is the outcome of one POST to an endpoint. A zero
+// NextAttemptAt on a failed attempt marks the delivery FAILED for good.
+type DeliveryAttempt struct {
+	Delivered     bool
+	ResponseCode  *int
+	ResponseBody  *string
+	ErrorMessage  *string
+	NextAttemptAt time.Time
+}
+
+const endpointColumns = `id, url, secret, events, active, created_at, updated_at`
+
+const deliveryColumns = `id, endpoint_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+	response_code, response_body, error_message, delivered_at, created_at, updated_at`
+
+type WebhookRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewWebhookRepo(pool *pgxpool.Pool) *WebhookRepo {
+	return &WebhookRepo{pool: pool}
+}
+
+func (r *WebhookRepo) CreateEndpoint(ctx context.Context, endpoint WebhookEndpoint) error {
+	events := endpoint.Events
+	if events == nil {
+		events = []string{}
+	}
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO webhook_endpoints (id, url, secret, events, active)
+		VALUES ($1, $2, $3, $4, $5)
+	`, endpoint.ID, endpoint.URL, endpoint.Secret, events, endpoint.Active)
+	if err != nil {
+		return fmt.Errorf("insert webhook endpoint: %w", err)
+	}
+	return nil
+}
+
+func (r *WebhookRepo) GetEndpoint(ctx context.Context, id uuid.UUID) (WebhookEndpoint, error) {
+	row := r.pool.QueryRow(ctx, `
+		SELECT `+endpointColumns+`
+		FROM webhook_endpoints
+		WHERE id = $1
+	`, id)
+	return scanEndpoint(row)
+}
+
+func (r *WebhookRepo) ListEndpoints(ctx context.Context) ([]WebhookEndpoint, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+endpointColumns+`
+		FROM webhook_endpoints
+		ORDER BY created_at
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook endpoints: %w", err)
+	}
+	defer rows.Close()
+
+	var endpoints []WebhookEndpoint
+	for rows.Next() {
+		endpoint, err := scanEndpoint(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan webhook endpoint: %w", err)
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list webhook endpoints: %w", err)
+	}
+	return endpoints, nil
+}
+
+func (r *WebhookRepo) DeleteEndpoint(ctx context.Context, id uuid.UUID) error {
+	cmd, err := r.pool.Exec(ctx, `DELETE FROM webhook_endpoints WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete webhook endpoint: %w", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func scanEndpoint(row pgx.Row) (WebhookEndpoint, error) {
+	var endpoint WebhookEndpoint
+	if err := row.Scan(
+		&endpoint.ID,
+		&endpoint.URL,
+		&endpoint.Secret,
+		&endpoint.Events,
+		&endpoint.Active,
+		&endpoint.CreatedAt,
+		&endpoint.UpdatedAt,
+	); err != nil {
+		return WebhookEndpoint{}, err
+	}
+	return endpoint, nil
+}
+
+// EnqueueDeliveries queues the event for every active endpoint subscribed to
+// its type; endpoints with an empty filter receive everything. It returns the
+// number of deliveries created.
+func (r *WebhookRepo) EnqueueDeliveries(ctx context.Context, eventID uuid.UUID, eventType string, payload []byte) (int64, error) {
+	cmd, err := r.pool.Exec(ctx, `
+		INSERT INTO webhook_deliveries (id, endpoint_id, event_id, event_type, payload, status)
+		SELECT gen_random_uuid(), id, $1, $2, $3, 'PENDING'
+		FROM webhook_endpoints
+		WHERE active AND (cardinality(events) = 0 OR $2 = ANY(events))
+		ON CONFLICT (endpoint_id, event_id) DO NOTHING
+	`, eventID, eventType, payload)
+	if err != nil {
+		return 0, fmt.Errorf("enqueue webhook deliveries: %w", err)
+	}
+	return cmd.RowsAffected(), nil
+}
+
+// ClaimDueDeliveries returns up to limit PENDING deliveries due at now, with
+// their endpoint's URL and secret, and pushes next_attempt_at forward by lease
+// so concurrent deliverers skip them.
+func (r *WebhookRepo) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]WebhookDelivery, error) {
+	rows, err := r.pool.Query(ctx, `
+		WITH claimed AS (
+			UPDATE webhook_deliveries
+			SET next_attempt_at = $1::timestamptz + $2 * interval '1 second', updated_at = now()
+			WHERE id IN (
+				SELECT id FROM webhook_deliveries
+				WHERE status = 'PENDING' AND next_attempt_at <= $1
+				ORDER BY next_attempt_at
+				LIMIT $3
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING `+deliveryColumns+`
+		)
+		SELECT claimed.*, e.url, e.secret
+		FROM claimed
+		JOIN webhook_endpoints e ON e.id = claimed.endpoint_id
+	`, now, lease.Seconds(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []WebhookDelivery
+	for rows.Next() {
+		var d WebhookDelivery
+		if err := rows.Scan(
+			&d.ID, &d.EndpointID, &d.EventID, &d.EventType, &d.Payload, &d.Status, &d.Attempts, &d.NextAttemptAt,
+			&d.ResponseCode, &d.ResponseBody, &d.ErrorMessage, &d.DeliveredAt, &d.CreatedAt, &d.UpdatedAt,
+			&d.URL, &d.Secret,
+		); err != nil {
+			return nil, fmt.Errorf("scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("claim webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (r *WebhookRepo) RecordAttempt(ctx context.Context, id uuid.UUID, attempt DeliveryAttempt) error {
+	status := "PENDING"
+	switch {
+	case attempt.Delivered:
+		status = "DELIVERED"
+	case attempt.NextAttemptAt.IsZero():
+		status = "FAILED"
+	}
+	var nextAttemptAt *time.Time
+	if !attempt.NextAttemptAt.IsZero() {
+		nextAttemptAt = &attempt.NextAttemptAt
+	}
+
+	cmd, err := r.pool.Exec(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = attempts + 1, response_code = $3, response_body = $4, error_message = $5,
+			next_attempt_at = COALESCE($6, next_attempt_at),
+			delivered_at = CASE WHEN $2 = 'DELIVERED' THEN now() ELSE delivered_at END,
+			updated_at = now()
+		WHERE id = $1
+	`, id, status, attempt.ResponseCode, attempt.ResponseBody, attempt.ErrorMessage, nextAttemptAt)
+	if err != nil {
+		return fmt.Errorf("record webhook attempt: %w", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *WebhookRepo) ListDeliveries(ctx context.Context, endpointID uuid.UUID, limit int) ([]WebhookDelivery, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+deliveryColumns+`
+		FROM webhook_deliveries
+		WHERE endpoint_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, endpointID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []WebhookDelivery
+	for rows.Next() {
+		var d WebhookDelivery
+		if err := rows.Scan(
+			&d.ID, &d.EndpointID, &d.EventID, &d.EventType, &d.Payload, &d.Status, &d.Attempts, &d.NextAttemptAt,
+			&d.ResponseCode, &d.ResponseBody, &d.ErrorMessage, &d.DeliveredAt, &d.CreatedAt, &d.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,63 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"logisync/internal/db/repo"
+	"logisync/internal/queue"
+	"logisync/internal/workerutil"
+)
+
+type ReconcilerConfig struct {
+	Stream string
+	// Group is the consumer group whose unread and pending messages count as
+	// still on their way to a worker.
+	Group string
+	// After is how long a job may sit in PENDING before it is re-enqueued,
+	// RECONCILE_PENDING_AFTER in the config. Jobs whose message is still
+	// outstanding in Group are left alone however long they wait.
+	After    time.Duration
+	Interval time.Duration
+	Batch    int
+	OnError  func(error)
+}
+
+// Reconciler re-enqueues PENDING jobs whose stream message was evidently
+// lost, e.g. created before the outbox existed or dropped by Redis. A job
+// whose last relayed message is still in the stream or a consumer's pending
+// list is only slow, not lost, and is skipped.
+type Reconciler struct {
+	outbox *repo.OutboxRepo
+	queue  queue.Queue
+	cfg    ReconcilerConfig
+}
+
+func NewReconciler(outbox *repo.OutboxRepo, q queue.Queue, cfg ReconcilerConfig) *Reconciler {
+	if cfg.After <= 0 {
+		cfg.After = 10 * time.Minute
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+	if cfg.Batch <= 0 {
+		cfg.Batch = 100
+	}
+	return &Reconciler{outbox: outbox, queue: q, cfg: cfg}
+}
+
+func (r *Reconciler) RunOnce(ctx context.Context) ([]repo.Job, error) {
+	cutoff := time.Now().UTC().Add(-r.cfg.After)
+	return r.outbox.RequeueStale(ctx, cutoff, r.cfg.Batch, r.cfg.Stream, func(job repo.Job) map[string]any {
+		return workerutil.NewMessage(job.ID, job.Provider, job.TrackingCode)
+	}, func(ctx context.Context, stream string, ids ...string) (map[string]bool, error) {
+		return r.queue.Outstanding(ctx, stream, r.cfg.Group, ids...)
+	})
+}
+
+func (r *Reconciler) Run(ctx context.Context) error {
+	return runLoop(ctx, r.cfg.Interval, r.cfg.OnError, func(ctx context.Context) error {
+		_, err := r.RunOnce(ctx)
+		return err
+	})
+}
@@ -0,0 +1,77 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"logisync/internal/db/repo"
+	"logisync/internal/queue"
+)
+
+type RelayConfig struct {
+	Interval time.Duration
+	Batch    int
+	OnError  func(error)
+}
+
+// Relay publishes committed outbox rows to their stream, one AddJobs call per
+// batch.
+type Relay struct {
+	outbox *repo.OutboxRepo
+	queue  queue.Queue
+	cfg    RelayConfig
+}
+
+func NewRelay(outbox *repo.OutboxRepo, q queue.Queue, cfg RelayConfig) *Relay {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 500 * time.Millisecond
+	}
+	if cfg.Batch <= 0 {
+		cfg.Batch = 100
+	}
+	return &Relay{outbox: outbox, queue: q, cfg: cfg}
+}
+
+func (r *Relay) RunOnce(ctx context.Context) (int, error) {
+	return r.outbox.Relay(ctx, r.cfg.Batch, func(ctx context.Context, msgs []repo.OutboxMessage) ([]string, error) {
+		batch := make([]queue.StreamMessage, len(msgs))
+		for i, msg := range msgs {
+			batch[i] = queue.StreamMessage{Stream: msg.Stream, Values: msg.Values}
+		}
+		return r.queue.AddJobs(ctx, batch)
+	})
+}
+
+// Run drains the outbox until empty on every tick.
+func (r *Relay) Run(ctx context.Context) error {
+	return runLoop(ctx, r.cfg.Interval, r.cfg.OnError, func(ctx context.Context) error {
+		for {
+			n, err := r.RunOnce(ctx)
+			if err != nil || n < r.cfg.Batch {
+				return err
+			}
+		}
+	})
+}
+
+func runLoop(ctx context.Context, interval time.Duration, onError func(error), tick func(context.Context) error) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		if err := tick(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
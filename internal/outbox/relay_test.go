@@ -0,0 +1,31 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRunLoopReportsErrorsUntilCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ticks := 0
+	var reported []error
+	err := runLoop(ctx, time.Millisecond, func(err error) { reported = append(reported, err) }, func(context.Context) error {
+		ticks++
+		if ticks == 3 {
+			cancel()
+			return errors.New("cancelled mid-tick")
+		}
+		return errors.New("redis down")
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if len(reported) != 2 {
+		t.Fatalf("expected errors before cancellation to be reported, got %v", reported)
+	}
+}
@@ -0,0 +1,209 @@
+package browserpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+var ErrClosed = errors.New("browser pool closed")
+
+type Config struct {
+	Size     int
+	MaxUses  int
+	Headless bool
+	SlowMo   time.Duration
+}
+
+type launcher interface {
+	Launch() (playwright.Browser, error)
+	Stop() error
+}
+
+type slot struct {
+	browser playwright.Browser
+	uses    int
+}
+
+// Pool keeps up to Size long-lived browsers and hands each job its own
+// BrowserContext on one of them. Browsers are relaunched after MaxUses jobs
+// or when they disconnect.
+type Pool struct {
+	cfg      Config
+	launcher launcher
+	slots    chan *slot
+
+	mu     sync.Mutex
+	closed bool
+}
+
+type Lease struct {
+	Context playwright.BrowserContext
+
+	pool *Pool
+	slot *slot
+	once sync.Once
+}
+
+func New(cfg Config) *Pool {
+	return newPool(cfg, &chromiumLauncher{headless: cfg.Headless, slowMo: cfg.SlowMo})
+}
+
+func newPool(cfg Config, l launcher) *Pool {
+	if cfg.Size <= 0 {
+		cfg.Size = 1
+	}
+	p := &Pool{cfg: cfg, launcher: l, slots: make(chan *slot, cfg.Size)}
+	for i := 0; i < cfg.Size; i++ {
+		p.slots <- &slot{}
+	}
+	return p
+}
+
+// Acquire waits for a free browser and opens a fresh context on it.
+func (p *Pool) Acquire(ctx context.Context) (*Lease, error) {
+	p.mu.Lock()
+	closed := p.closed
+	p.mu.Unlock()
+	if closed {
+		return nil, ErrClosed
+	}
+
+	var s *slot
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case s = <-p.slots:
+	}
+
+	p.mu.Lock()
+	closed = p.closed
+	p.mu.Unlock()
+	if closed {
+		p.slots <- s
+		return nil, ErrClosed
+	}
+
+	if err := p.ensureBrowser(s); err != nil {
+		p.slots <- s
+		return nil, err
+	}
+
+	bctx, err := s.browser.NewContext()
+	if err != nil {
+		p.recycle(s)
+		p.slots <- s
+		return nil, fmt.Errorf("new browser context: %w", err)
+	}
+	return &Lease{Context: bctx, pool: p, slot: s}, nil
+}
+
+// Release closes the job's context and returns the browser to the pool.
+// It is safe to call more than once.
+func (l *Lease) Release() {
+	l.once.Do(func() {
+		_ = l.Context.Close()
+		l.slot.uses++
+		if !l.slot.browser.IsConnected() || (l.pool.cfg.MaxUses > 0 && l.slot.uses >= l.pool.cfg.MaxUses) {
+			l.pool.recycle(l.slot)
+		}
+		l.pool.slots <- l.slot
+	})
+}
+
+// Close waits for in-flight leases (or ctx), closes every browser and stops
+// the Playwright driver.
+func (p *Pool) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.mu.Unlock()
+
+	drained := make([]*slot, 0, p.cfg.Size)
+	defer func() {
+		// Hand the slots back so later Acquire calls see ErrClosed instead of blocking.
+		for _, s := range drained {
+			p.slots <- s
+		}
+	}()
+	for i := 0; i < p.cfg.Size; i++ {
+		select {
+		case <-ctx.Done():
+			_ = p.launcher.Stop()
+			return ctx.Err()
+		case s := <-p.slots:
+			p.recycle(s)
+			drained = append(drained, s)
+		}
+	}
+	return p.launcher.Stop()
+}
+
+func (p *Pool) ensureBrowser(s *slot) error {
+	if s.browser != nil && s.browser.IsConnected() {
+		return nil
+	}
+	p.recycle(s)
+	browser, err := p.launcher.Launch()
+	if err != nil {
+		return err
+	}
+	s.browser = browser
+	return nil
+}
+
+func (p *Pool) recycle(s *slot) {
+	if s.browser != nil {
+		_ = s.browser.Close()
+	}
+	s.browser = nil
+	s.uses = 0
+}
+
+type chromiumLauncher struct {
+	headless bool
+	slowMo   time.Duration
+
+	mu sync.Mutex
+	pw *playwright.Playwright
+}
+
+func (c *chromiumLauncher) Launch() (playwright.Browser, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pw == nil {
+		pw, err := playwright.Run()
+		if err != nil {
+			return nil, fmt.Errorf("start playwright: %w", err)
+		}
+		c.pw = pw
+	}
+
+	opts := playwright.BrowserTypeLaunchOptions{Headless: playwright.Bool(c.headless)}
+	if c.slowMo > 0 {
+		opts.SlowMo = playwright.Float(float64(c.slowMo.Milliseconds()))
+	}
+	browser, err := c.pw.Chromium.Launch(opts)
+	if err != nil {
+		return nil, fmt.Errorf("launch browser: %w", err)
+	}
+	return browser, nil
+}
+
+func (c *chromiumLauncher) Stop() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pw == nil {
+		return nil
+	}
+	err := c.pw.Stop()
+	c.pw = nil
+	return err
+}
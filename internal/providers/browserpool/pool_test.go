@@ -0,0 +1,134 @@
+package browserpool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+type fakeContext struct {
+	playwright.BrowserContext
+	closed bool
+}
+
+func (f *fakeContext) Close(options ...playwright.BrowserContextCloseOptions) error {
+	f.closed = true
+	return nil
+}
+
+type fakeBrowser struct {
+	playwright.Browser
+	connected bool
+	closed    bool
+}
+
+func (f *fakeBrowser) NewContext(options ...playwright.BrowserNewContextOptions) (playwright.BrowserContext, error) {
+	return &fakeContext{}, nil
+}
+
+func (f *fakeBrowser) IsConnected() bool { return f.connected && !f.closed }
+
+func (f *fakeBrowser) Close(options ...playwright.BrowserCloseOptions) error {
+	f.closed = true
+	return nil
+}
+
+type fakeLauncher struct {
+	launched []*fakeBrowser
+	stopped  bool
+}
+
+func (f *fakeLauncher) Launch() (playwright.Browser, error) {
+	b := &fakeBrowser{connected: true}
+	f.launched = append(f.launched, b)
+	return b, nil
+}
+
+func (f *fakeLauncher) Stop() error {
+	f.stopped = true
+	return nil
+}
+
+func TestPoolReusesAndRecyclesBrowsers(t *testing.T) {
+	launcher := &fakeLauncher{}
+	pool := newPool(Config{Size: 1, MaxUses: 2}, launcher)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		lease, err := pool.Acquire(ctx)
+		if err != nil {
+			t.Fatalf("acquire: %v", err)
+		}
+		lease.Release()
+		if !lease.Context.(*fakeContext).closed {
+			t.Fatalf("expected context to be closed on release")
+		}
+	}
+
+	if len(launcher.launched) != 2 {
+		t.Fatalf("expected 2 launches with MaxUses=2, got %d", len(launcher.launched))
+	}
+	if !launcher.launched[0].closed {
+		t.Fatalf("expected first browser to be recycled")
+	}
+}
+
+func TestPoolRelaunchesCrashedBrowser(t *testing.T) {
+	launcher := &fakeLauncher{}
+	pool := newPool(Config{Size: 1}, launcher)
+	ctx := context.Background()
+
+	lease, err := pool.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	launcher.launched[0].connected = false
+	lease.Release()
+
+	lease, err = pool.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	lease.Release()
+	if len(launcher.launched) != 2 {
+		t.Fatalf("expected relaunch after crash, got %d launches", len(launcher.launched))
+	}
+}
+
+func TestPoolBoundsConcurrency(t *testing.T) {
+	pool := newPool(Config{Size: 1}, &fakeLauncher{})
+	lease, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	defer lease.Release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded while pool is exhausted, got %v", err)
+	}
+}
+
+func TestPoolClose(t *testing.T) {
+	launcher := &fakeLauncher{}
+	pool := newPool(Config{Size: 2}, launcher)
+	lease, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	lease.Release()
+
+	if err := pool.Close(context.Background()); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if !launcher.stopped || !launcher.launched[0].closed {
+		t.Fatalf("expected browsers closed and driver stopped")
+	}
+	if _, err := pool.Acquire(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+}
@@ -22,21 +22,23 @@ func (p *Provider) Name() string {
 
 func (p *Provider) Track(ctx context.Context, trackingCode string) (providers.Result, error) {
 	_ = ctx
-	payload := map[string]any{
-		"provider":      p.Name(),
-		"tracking_code": trackingCode,
-		"status":        "IN_TRANSIT",
-		"last_update":   time.Now().UTC().Format(time.RFC3339),
-		"events": []map[string]any{
+	now := time.Now().UTC().Truncate(time.Second)
+	payload := providers.Shipment{
+		Provider:     p.Name(),
+		TrackingCode: trackingCode,
+		Status:       providers.StatusInTransit,
+		RawStatus:    "IN_TRANSIT",
+		LastUpdate:   now,
+		Events: []providers.TrackingEvent{
 			{
-				"timestamp":   time.Now().UTC().Format(time.RFC3339),
-				"location":    "SAO PAULO - SP",
-				"description": "Dummy tracking event",
+				Timestamp:   now,
+				Location:    providers.ParseLocation("SAO PAULO - SP", "BR"),
+				Description: "Dummy tracking event",
+				Status:      providers.StatusInTransit,
+				RawStatus:   "IN_TRANSIT",
 			},
 		},
-		"raw": map[string]any{
-			"source": "dummy",
-		},
+		Raw: json.RawMessage(`{"source":"dummy"}`),
 	}
 
 	payloadBytes, _ := json.MarshalIndent(payload, "", "  ")
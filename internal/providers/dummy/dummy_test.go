@@ -12,8 +12,12 @@ func TestDummyTrack(t *testing.T) {
 		t.Fatalf("track: %v", err)
 	}
 
-	if result.Payload["tracking_code"] != "TEST123" {
-		t.Fatalf("unexpected tracking_code: %v", result.Payload["tracking_code"])
+	if result.Payload.TrackingCode != "TEST123" {
+		t.Fatalf("unexpected tracking_code: %v", result.Payload.TrackingCode)
+	}
+
+	if err := result.Payload.Validate(); err != nil {
+		t.Fatalf("expected valid shipment: %v", err)
 	}
 
 	if len(result.Artifacts) != 1 {
@@ -0,0 +1,14 @@
+package dummy
+
+import "logisync/internal/providers"
+
+var Capabilities = providers.Capabilities{
+	Countries:        []string{"BR"},
+	ReturnsArtifacts: true,
+}
+
+func Register(registry *providers.Registry, name string) error {
+	return registry.Register(name, func() (providers.Provider, error) {
+		return New(name), nil
+	}, Capabilities)
+}
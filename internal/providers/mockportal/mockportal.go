@@ -10,6 +10,7 @@ import (
 	"github.com/playwright-community/playwright-go"
 
 	"logisync/internal/providers"
+	"logisync/internal/providers/browserpool"
 )
 
 type Config struct {
@@ -17,10 +18,23 @@ type Config struct {
 	Timeout  time.Duration
 	Headless bool
 	SlowMo   time.Duration // Slow motion delay between actions (for debugging)
+	// Browsers, when set, supplies pooled browser contexts instead of
+	// launching Chromium for every job.
+	Browsers *browserpool.Pool
 }
 
 type Provider struct {
-	cfg Config
+	name string
+	cfg  Config
+}
+
+// StatusTable holds the portal's own phrases; anything else falls through to
+// providers.CommonStatusTable and the classifier.
+var StatusTable = providers.StatusTable{
+	"posted":             providers.StatusCreated,
+	"at sorting center":  providers.StatusInTransit,
+	"delivery attempted": providers.StatusException,
+	"ready for pickup":   providers.StatusAwaitingPickup,
 }
 
 type trackResponse struct {
@@ -36,12 +50,12 @@ type event struct {
 	Description string `json:"description"`
 }
 
-func New(cfg Config) *Provider {
-	return &Provider{cfg: cfg}
+func New(name string, cfg Config) *Provider {
+	return &Provider{name: name, cfg: cfg}
 }
 
 func (p *Provider) Name() string {
-	return "mock_portal_scrape"
+	return p.name
 }
 
 func (p *Provider) Track(ctx context.Context, trackingCode string) (providers.Result, error) {
@@ -49,11 +63,76 @@ func (p *Provider) Track(ctx context.Context, trackingCode string) (providers.Re
 		return providers.Result{}, &providers.Error{Code: "INVALID_INPUT", Message: "missing mock portal url"}
 	}
 
+	page, release, err := p.openPage(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return providers.Result{}, contextError(ctx)
+		}
+		return providers.Result{}, err
+	}
+	defer release()
+
+	stop := closeOnDone(ctx, page)
+	result, err := p.runFlow(ctx, page, trackingCode)
+	stop()
+	if err != nil {
+		if ctx.Err() != nil {
+			return providers.Result{}, contextError(ctx)
+		}
+		if providerErr := p.attachFailureArtifacts(page, err); providerErr != nil {
+			return providers.Result{}, providerErr
+		}
+		return providers.Result{}, err
+	}
+
+	return result, nil
+}
+
+// closeOnDone closes page as soon as ctx is done, so a Playwright call
+// blocked on the portal fails right away instead of running into its own
+// timeout. The returned stop must be called once the flow is over.
+func closeOnDone(ctx context.Context, page playwright.Page) func() {
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		select {
+		case <-ctx.Done():
+			page.Close()
+		case <-done:
+		}
+	}()
+	return func() {
+		close(done)
+		<-finished
+	}
+}
+
+func contextError(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &providers.Error{Code: "TIMEOUT", Message: "tracking deadline exceeded", Err: ctx.Err()}
+	}
+	return &providers.Error{Code: "CANCELLED", Message: "tracking cancelled", Err: ctx.Err()}
+}
+
+func (p *Provider) openPage(ctx context.Context) (playwright.Page, func(), error) {
+	if p.cfg.Browsers != nil {
+		lease, err := p.cfg.Browsers.Acquire(ctx)
+		if err != nil {
+			return nil, nil, &providers.Error{Code: "PROVIDER_ERROR", Message: "failed to acquire browser", Err: err}
+		}
+		page, err := lease.Context.NewPage()
+		if err != nil {
+			lease.Release()
+			return nil, nil, &providers.Error{Code: "PROVIDER_ERROR", Message: "failed to open page", Err: err}
+		}
+		return page, lease.Release, nil
+	}
+
 	pw, err := playwright.Run()
 	if err != nil {
-		return providers.Result{}, &providers.Error{Code: "PROVIDER_ERROR", Message: "failed to start playwright", Err: err}
+		return nil, nil, &providers.Error{Code: "PROVIDER_ERROR", Message: "failed to start playwright", Err: err}
 	}
-	defer pw.Stop()
 
 	launchOpts := playwright.BrowserTypeLaunchOptions{
 		Headless: playwright.Bool(p.cfg.Headless),
@@ -63,24 +142,20 @@ func (p *Provider) Track(ctx context.Context, trackingCode string) (providers.Re
 	}
 	browser, err := pw.Chromium.Launch(launchOpts)
 	if err != nil {
-		return providers.Result{}, &providers.Error{Code: "PROVIDER_ERROR", Message: "failed to launch browser", Err: err}
+		pw.Stop()
+		return nil, nil, &providers.Error{Code: "PROVIDER_ERROR", Message: "failed to launch browser", Err: err}
 	}
-	defer browser.Close()
-
-	page, err := browser.NewPage()
-	if err != nil {
-		return providers.Result{}, &providers.Error{Code: "PROVIDER_ERROR", Message: "failed to open page", Err: err}
+	release := func() {
+		browser.Close()
+		pw.Stop()
 	}
 
-	result, err := p.runFlow(ctx, page, trackingCode)
+	page, err := browser.NewPage()
 	if err != nil {
-		if providerErr := p.attachFailureArtifacts(page, err); providerErr != nil {
-			return providers.Result{}, providerErr
-		}
-		return providers.Result{}, err
+		release()
+		return nil, nil, &providers.Error{Code: "PROVIDER_ERROR", Message: "failed to open page", Err: err}
 	}
-
-	return result, nil
+	return page, release, nil
 }
 
 func (p *Provider) runFlow(ctx context.Context, page playwright.Page, trackingCode string) (providers.Result, error) {
@@ -122,24 +197,9 @@ func (p *Provider) runFlow(ctx context.Context, page playwright.Page, trackingCo
 		return providers.Result{}, &providers.Error{Code: "PARSE_ERROR", Message: "failed to parse response", Err: err}
 	}
 
-	events := make([]map[string]any, 0, len(apiResp.Events))
-	for _, evt := range apiResp.Events {
-		events = append(events, map[string]any{
-			"timestamp":   evt.Timestamp,
-			"location":    evt.Location,
-			"description": evt.Description,
-		})
-	}
-
-	payload := map[string]any{
-		"provider":      p.Name(),
-		"tracking_code": apiResp.TrackingCode,
-		"status":        apiResp.Status,
-		"last_update":   apiResp.LastUpdate,
-		"events":        events,
-		"raw": map[string]any{
-			"response": json.RawMessage(body),
-		},
+	payload, err := p.toShipment(apiResp, body)
+	if err != nil {
+		return providers.Result{}, err
 	}
 
 	return providers.Result{
@@ -155,6 +215,47 @@ func (p *Provider) runFlow(ctx context.Context, page playwright.Page, trackingCo
 	}, nil
 }
 
+// StatusTable lets a providers.StatusNormalizer map the portal's phrases and
+// record the ones it does not know.
+func (p *Provider) StatusTable() providers.StatusTable {
+	return StatusTable
+}
+
+func (p *Provider) toShipment(apiResp trackResponse, body []byte) (providers.Shipment, error) {
+	lastUpdate, err := providers.ParseTimestamp(apiResp.LastUpdate)
+	if err != nil {
+		return providers.Shipment{}, &providers.Error{Code: "PARSE_ERROR", Message: "failed to parse last_update", Err: err}
+	}
+
+	events := make([]providers.TrackingEvent, 0, len(apiResp.Events))
+	for _, evt := range apiResp.Events {
+		ts, err := providers.ParseTimestamp(evt.Timestamp)
+		if err != nil {
+			return providers.Shipment{}, &providers.Error{Code: "PARSE_ERROR", Message: "failed to parse event timestamp", Err: err}
+		}
+		// The portal has no per-event status; the description is its phrase.
+		eventStatus, _ := providers.MapStatus(StatusTable, evt.Description)
+		events = append(events, providers.TrackingEvent{
+			Timestamp:   ts,
+			Location:    providers.ParseLocation(evt.Location, "BR"),
+			Description: evt.Description,
+			Status:      eventStatus,
+			RawStatus:   evt.Description,
+		})
+	}
+
+	status, _ := providers.MapStatus(StatusTable, apiResp.Status)
+	return providers.Shipment{
+		Provider:     p.Name(),
+		TrackingCode: apiResp.TrackingCode,
+		Status:       status,
+		RawStatus:    apiResp.Status,
+		LastUpdate:   lastUpdate,
+		Events:       events,
+		Raw:          json.RawMessage(body),
+	}, nil
+}
+
 func (p *Provider) attachFailureArtifacts(page playwright.Page, err error) error {
 	providerErr := &providers.Error{Code: "PROVIDER_ERROR", Message: "tracking failed", Err: err}
 	var existing *providers.Error
@@ -3,7 +3,12 @@ package mockportal
 import (
 	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
 
 	"logisync/internal/providers"
 )
@@ -28,7 +33,7 @@ func TestMapHTTPError(t *testing.T) {
 }
 
 func TestTrackMissingBaseURL(t *testing.T) {
-	provider := New(Config{BaseURL: ""})
+	provider := New("mock_portal_scrape", Config{BaseURL: ""})
 	_, err := provider.Track(context.TODO(), "AA123")
 	var providerErr *providers.Error
 	if err == nil || !errors.As(err, &providerErr) {
@@ -40,7 +45,7 @@ func TestTrackMissingBaseURL(t *testing.T) {
 }
 
 func TestAttachFailureArtifactsNilPage(t *testing.T) {
-	provider := New(Config{BaseURL: "http://localhost"})
+	provider := New("mock_portal_scrape", Config{BaseURL: "http://localhost"})
 	err := provider.attachFailureArtifacts(nil, errors.New("boom"))
 	var providerErr *providers.Error
 	if !errors.As(err, &providerErr) {
@@ -53,3 +58,119 @@ func TestAttachFailureArtifactsNilPage(t *testing.T) {
 		t.Fatalf("expected no artifacts")
 	}
 }
+
+func TestHealth(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	provider := New("mock_portal_scrape", Config{BaseURL: server.URL})
+	if err := provider.Health(context.TODO()); err != nil {
+		t.Fatalf("expected healthy, got %v", err)
+	}
+
+	status = http.StatusBadGateway
+	if err := provider.Health(context.TODO()); err == nil {
+		t.Fatalf("expected unhealthy on 502")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	registry := providers.NewRegistry()
+	if err := Register(registry, "portal_br", Config{BaseURL: "http://localhost"}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if err := registry.Validate("portal_br", "AA123"); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if err := registry.Validate("portal_br", "AA 123"); err == nil {
+		t.Fatalf("expected invalid code")
+	}
+	provider, err := registry.Get("portal_br")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if provider.Name() != "portal_br" {
+		t.Fatalf("expected the registered name, got %s", provider.Name())
+	}
+}
+
+func TestToShipment(t *testing.T) {
+	provider := New("mock_portal_scrape", Config{BaseURL: "http://localhost"})
+	body := []byte(`{"tracking_code":"AA123"}`)
+	shipment, err := provider.toShipment(trackResponse{
+		TrackingCode: "AA123",
+		Status:       "IN_TRANSIT",
+		LastUpdate:   "2024-01-02 10:30:00",
+		Events: []event{
+			{Timestamp: "02/01/2024 10:30", Location: "CURITIBA - PR", Description: "Em trânsito"},
+		},
+	}, body)
+	if err != nil {
+		t.Fatalf("to shipment: %v", err)
+	}
+	if err := shipment.Validate(); err != nil {
+		t.Fatalf("expected valid shipment: %v", err)
+	}
+	if shipment.Events[0].Location.City != "CURITIBA" || shipment.Events[0].Location.State != "PR" {
+		t.Fatalf("unexpected location: %+v", shipment.Events[0].Location)
+	}
+	if shipment.Events[0].RawStatus != "Em trânsito" || shipment.Events[0].Status != providers.StatusInTransit {
+		t.Fatalf("unexpected event status: %s/%q", shipment.Events[0].Status, shipment.Events[0].RawStatus)
+	}
+
+	_, err = provider.toShipment(trackResponse{TrackingCode: "AA123", LastUpdate: "soon"}, body)
+	var providerErr *providers.Error
+	if !errors.As(err, &providerErr) || providerErr.Code != "PARSE_ERROR" {
+		t.Fatalf("expected PARSE_ERROR, got %v", err)
+	}
+}
+
+type closingPage struct {
+	playwright.Page
+	closed chan struct{}
+}
+
+func (p *closingPage) Close(...playwright.PageCloseOptions) error {
+	close(p.closed)
+	return nil
+}
+
+func TestCloseOnDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	page := &closingPage{closed: make(chan struct{})}
+	stop := closeOnDone(ctx, page)
+
+	cancel()
+	select {
+	case <-page.closed:
+	case <-time.After(time.Second):
+		t.Fatalf("expected page to be closed on cancellation")
+	}
+	stop()
+
+	finished := &closingPage{closed: make(chan struct{})}
+	closeOnDone(context.Background(), finished)()
+	select {
+	case <-finished.closed:
+		t.Fatalf("expected page to stay open when the flow finished first")
+	default:
+	}
+}
+
+func TestContextError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var providerErr *providers.Error
+	if !errors.As(contextError(ctx), &providerErr) || providerErr.Code != "CANCELLED" {
+		t.Fatalf("expected CANCELLED, got %v", providerErr)
+	}
+
+	ctx, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	if !errors.As(contextError(ctx), &providerErr) || providerErr.Code != "TIMEOUT" {
+		t.Fatalf("expected TIMEOUT, got %v", providerErr)
+	}
+}
@@ -0,0 +1,52 @@
+package mockportal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"logisync/internal/providers"
+)
+
+var Capabilities = providers.Capabilities{
+	TrackingCodePatterns: []string{`^[A-Za-z0-9]{4,40}$`},
+	NeedsBrowser:         true,
+	MaxConcurrency:       2,
+	RateLimitPerMinute:   60,
+	Countries:            []string{"BR"},
+	ReturnsArtifacts:     true,
+}
+
+// healthClient gives up on a probe after healthTimeout even when the caller's
+// context has no deadline.
+var healthClient = &http.Client{Timeout: healthTimeout}
+
+const healthTimeout = 5 * time.Second
+
+func Register(registry *providers.Registry, name string, cfg Config) error {
+	return registry.Register(name, func() (providers.Provider, error) {
+		return New(name, cfg), nil
+	}, Capabilities)
+}
+
+// Health checks that the portal's tracking page answers without a 5xx.
+func (p *Provider) Health(ctx context.Context) error {
+	if strings.TrimSpace(p.cfg.BaseURL) == "" {
+		return fmt.Errorf("missing mock portal url")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(p.cfg.BaseURL, "/")+"/track", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := healthClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("mock portal returned %d", resp.StatusCode)
+	}
+	return nil
+}
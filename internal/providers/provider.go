@@ -12,7 +12,7 @@ type Artifact struct {
 }
 
 type Result struct {
-	Payload   map[string]any
+	Payload   Shipment
 	Artifacts []Artifact
 }
 
@@ -0,0 +1,194 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnknownProvider    = errors.New("unknown provider")
+	ErrUnsupportedCode    = errors.New("tracking code not supported by provider")
+	ErrProviderRegistered = errors.New("provider already registered")
+)
+
+type Capabilities struct {
+	TrackingCodePatterns []string `json:"tracking_code_patterns,omitempty"`
+	NeedsBrowser         bool     `json:"needs_browser"`
+	MaxConcurrency       int      `json:"max_concurrency,omitempty"`
+	RateLimitPerMinute   int      `json:"rate_limit_per_minute,omitempty"`
+	Countries            []string `json:"countries,omitempty"`
+	ReturnsArtifacts     bool     `json:"returns_artifacts"`
+}
+
+type Factory func() (Provider, error)
+
+// healthTimeout bounds each provider's probe in List, so one unreachable
+// upstream cannot stall the listing.
+const healthTimeout = 5 * time.Second
+
+// HealthChecker is implemented by providers that can report whether their
+// upstream is reachable.
+type HealthChecker interface {
+	Health(ctx context.Context) error
+}
+
+type Info struct {
+	Name         string       `json:"name"`
+	Capabilities Capabilities `json:"capabilities"`
+	Healthy      bool         `json:"healthy"`
+	Error        string       `json:"error,omitempty"`
+}
+
+type registration struct {
+	factory  Factory
+	caps     Capabilities
+	patterns []*regexp.Regexp
+
+	once     sync.Once
+	provider Provider
+	err      error
+}
+
+type Registry struct {
+	mu            sync.RWMutex
+	entries       map[string]*registration
+	normalizer    *StatusNormalizer
+	onError       func(error)
+	healthTimeout time.Duration
+}
+
+func NewRegistry() *Registry {
+	return &Registry{entries: map[string]*registration{}, healthTimeout: healthTimeout}
+}
+
+func (r *Registry) Register(name string, factory Factory, caps Capabilities) error {
+	patterns := make([]*regexp.Regexp, 0, len(caps.TrackingCodePatterns))
+	for _, expr := range caps.TrackingCodePatterns {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("provider %s: invalid tracking code pattern %q: %w", name, expr, err)
+		}
+		patterns = append(patterns, re)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.entries[name]; ok {
+		return fmt.Errorf("%w: %s", ErrProviderRegistered, name)
+	}
+	r.entries[name] = &registration{factory: factory, caps: caps, patterns: patterns}
+	return nil
+}
+
+// SetStatusNormalizer routes the results of every provider built afterwards
+// through n; failures to record unmapped phrases go to onError. Call it
+// before the first Get.
+func (r *Registry) SetStatusNormalizer(n *StatusNormalizer, onError func(error)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.normalizer = n
+	r.onError = onError
+}
+
+// Get returns the provider instance, building it on first use.
+func (r *Registry) Get(name string) (Provider, error) {
+	reg, err := r.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	reg.once.Do(func() {
+		reg.provider, reg.err = reg.factory()
+		r.mu.RLock()
+		normalizer, onError := r.normalizer, r.onError
+		r.mu.RUnlock()
+		if reg.err == nil && normalizer != nil {
+			reg.provider = WithStatusNormalizer(reg.provider, normalizer, onError)
+		}
+	})
+	if reg.err != nil {
+		return nil, fmt.Errorf("build provider %s: %w", name, reg.err)
+	}
+	return reg.provider, nil
+}
+
+func (r *Registry) Capabilities(name string) (Capabilities, error) {
+	reg, err := r.lookup(name)
+	if err != nil {
+		return Capabilities{}, err
+	}
+	return reg.caps, nil
+}
+
+// Validate checks that name is registered and, when the provider declares
+// tracking code patterns, that trackingCode matches one of them.
+func (r *Registry) Validate(name, trackingCode string) error {
+	reg, err := r.lookup(name)
+	if err != nil {
+		return err
+	}
+	if len(reg.patterns) == 0 {
+		return nil
+	}
+	for _, re := range reg.patterns {
+		if re.MatchString(trackingCode) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrUnsupportedCode, name)
+}
+
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.entries))
+	for name := range r.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// List describes every registered provider, running health checks for those
+// that implement HealthChecker. Each check gets at most healthTimeout.
+func (r *Registry) List(ctx context.Context) []Info {
+	names := r.Names()
+	infos := make([]Info, 0, len(names))
+	for _, name := range names {
+		reg, _ := r.lookup(name)
+		info := Info{Name: name, Capabilities: reg.caps, Healthy: true}
+
+		provider, err := r.Get(name)
+		if err == nil {
+			if checker, ok := provider.(HealthChecker); ok {
+				err = r.health(ctx, checker)
+			}
+		}
+		if err != nil {
+			info.Healthy = false
+			info.Error = err.Error()
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+func (r *Registry) health(ctx context.Context, checker HealthChecker) error {
+	ctx, cancel := context.WithTimeout(ctx, r.healthTimeout)
+	defer cancel()
+	return checker.Health(ctx)
+}
+
+func (r *Registry) lookup(name string) (*registration, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	reg, ok := r.entries[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+	return reg, nil
+}
@@ -0,0 +1,197 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type stubProvider struct {
+	name      string
+	healthErr error
+	hang      bool
+}
+
+func (s *stubProvider) Name() string { return s.name }
+
+func (s *stubProvider) Track(ctx context.Context, trackingCode string) (Result, error) {
+	return Result{}, nil
+}
+
+func (s *stubProvider) Health(ctx context.Context) error {
+	if s.hang {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return s.healthErr
+}
+
+func TestRegistryValidate(t *testing.T) {
+	registry := NewRegistry()
+	err := registry.Register("stub", func() (Provider, error) {
+		return &stubProvider{name: "stub"}, nil
+	}, Capabilities{TrackingCodePatterns: []string{`^[A-Z]{2}[0-9]+$`}})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	if err := registry.Validate("stub", "AA123"); err != nil {
+		t.Fatalf("expected valid code, got %v", err)
+	}
+	if err := registry.Validate("stub", "123"); !errors.Is(err, ErrUnsupportedCode) {
+		t.Fatalf("expected ErrUnsupportedCode, got %v", err)
+	}
+	if err := registry.Validate("missing", "AA123"); !errors.Is(err, ErrUnknownProvider) {
+		t.Fatalf("expected ErrUnknownProvider, got %v", err)
+	}
+}
+
+func TestRegistryRejectsDuplicatesAndBadPatterns(t *testing.T) {
+	registry := NewRegistry()
+	factory := func() (Provider, error) { return &stubProvider{name: "stub"}, nil }
+	if err := registry.Register("stub", factory, Capabilities{}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if err := registry.Register("stub", factory, Capabilities{}); !errors.Is(err, ErrProviderRegistered) {
+		t.Fatalf("expected ErrProviderRegistered, got %v", err)
+	}
+	if err := registry.Register("bad", factory, Capabilities{TrackingCodePatterns: []string{"("}}); err == nil {
+		t.Fatalf("expected invalid pattern error")
+	}
+}
+
+func TestRegistryGetBuildsOnce(t *testing.T) {
+	registry := NewRegistry()
+	builds := 0
+	_ = registry.Register("stub", func() (Provider, error) {
+		builds++
+		return &stubProvider{name: "stub"}, nil
+	}, Capabilities{})
+
+	for i := 0; i < 3; i++ {
+		if _, err := registry.Get("stub"); err != nil {
+			t.Fatalf("get: %v", err)
+		}
+	}
+	if builds != 1 {
+		t.Fatalf("expected 1 build, got %d", builds)
+	}
+}
+
+func TestRegistryListHealth(t *testing.T) {
+	registry := NewRegistry()
+	_ = registry.Register("healthy", func() (Provider, error) {
+		return &stubProvider{name: "healthy"}, nil
+	}, Capabilities{ReturnsArtifacts: true})
+	_ = registry.Register("down", func() (Provider, error) {
+		return &stubProvider{name: "down", healthErr: errors.New("portal down")}, nil
+	}, Capabilities{NeedsBrowser: true})
+
+	infos := registry.List(context.TODO())
+	if len(infos) != 2 {
+		t.Fatalf("expected 2 providers, got %d", len(infos))
+	}
+	if infos[0].Name != "down" || infos[0].Healthy || infos[0].Error != "portal down" {
+		t.Fatalf("unexpected info for down: %+v", infos[0])
+	}
+	if infos[1].Name != "healthy" || !infos[1].Healthy || !infos[1].Capabilities.ReturnsArtifacts {
+		t.Fatalf("unexpected info for healthy: %+v", infos[1])
+	}
+}
+
+func TestRegistryListBoundsHealthChecks(t *testing.T) {
+	registry := NewRegistry()
+	registry.healthTimeout = 10 * time.Millisecond
+	_ = registry.Register("hung", func() (Provider, error) {
+		return &stubProvider{name: "hung", hang: true}, nil
+	}, Capabilities{})
+
+	done := make(chan []Info, 1)
+	go func() { done <- registry.List(context.Background()) }()
+	select {
+	case infos := <-done:
+		if len(infos) != 1 || infos[0].Healthy {
+			t.Fatalf("expected the hung provider to be unhealthy, got %+v", infos)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("List did not bound the health check")
+	}
+}
+
+type phraseProvider struct {
+	stubProvider
+	phrase string
+}
+
+func (p *phraseProvider) Track(ctx context.Context, trackingCode string) (Result, error) {
+	shipment := validShipment()
+	shipment.Provider = p.name
+	shipment.RawStatus = p.phrase
+	return Result{Payload: shipment}, nil
+}
+
+func (p *phraseProvider) StatusTable() StatusTable {
+	return StatusTable{"coletado": StatusInTransit}
+}
+
+func TestRegistryNormalizesStatuses(t *testing.T) {
+	recorder := &memoryRecorder{}
+	registry := NewRegistry()
+	registry.SetStatusNormalizer(NewStatusNormalizer(recorder), nil)
+	for name, phrase := range map[string]string{"known": "Coletado", "unknown": "Aguardando pagamento"} {
+		phrase := phrase
+		_ = registry.Register(name, func() (Provider, error) {
+			return &phraseProvider{stubProvider: stubProvider{name: name}, phrase: phrase}, nil
+		}, Capabilities{})
+	}
+
+	provider, _ := registry.Get("known")
+	result, err := provider.Track(context.Background(), "AA123")
+	if err != nil {
+		t.Fatalf("track: %v", err)
+	}
+	if result.Payload.Status != StatusInTransit {
+		t.Fatalf("expected provider table to map status, got %s", result.Payload.Status)
+	}
+	if len(recorder.records) != 0 {
+		t.Fatalf("expected nothing recorded, got %+v", recorder.records)
+	}
+
+	provider, _ = registry.Get("unknown")
+	if _, err := provider.Track(context.Background(), "AA123"); err != nil {
+		t.Fatalf("track: %v", err)
+	}
+	if len(recorder.records) != 1 || recorder.records[0].provider != "unknown" || recorder.records[0].raw != "Aguardando pagamento" {
+		t.Fatalf("expected unmapped status to be recorded, got %+v", recorder.records)
+	}
+	if _, ok := provider.(HealthChecker); !ok {
+		t.Fatalf("expected wrapped provider to keep its health check")
+	}
+}
+
+type failingRecorder struct{}
+
+func (failingRecorder) RecordUnmapped(ctx context.Context, provider, rawStatus string, guess ShipmentStatus) error {
+	return errors.New("database unavailable")
+}
+
+func TestStatusNormalizerFailureKeepsResult(t *testing.T) {
+	var reported []error
+	provider := WithStatusNormalizer(
+		&phraseProvider{stubProvider: stubProvider{name: "stub"}, phrase: "Objeto entregue na caixa de correio"},
+		NewStatusNormalizer(failingRecorder{}),
+		func(err error) { reported = append(reported, err) },
+	)
+
+	result, err := provider.Track(context.Background(), "AA123")
+	if err != nil {
+		t.Fatalf("expected the lookup to succeed, got %v", err)
+	}
+	if result.Payload.Status != StatusDelivered {
+		t.Fatalf("expected classified status, got %s", result.Payload.Status)
+	}
+	if len(reported) != 1 {
+		t.Fatalf("expected the recording failure to be reported once, got %v", reported)
+	}
+}
@@ -0,0 +1,178 @@
+package providers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type ShipmentStatus string
+
+const (
+	StatusUnknown        ShipmentStatus = "UNKNOWN"
+	StatusCreated        ShipmentStatus = "CREATED"
+	StatusInTransit      ShipmentStatus = "IN_TRANSIT"
+	StatusOutForDelivery ShipmentStatus = "OUT_FOR_DELIVERY"
+	StatusAwaitingPickup ShipmentStatus = "AWAITING_PICKUP"
+	StatusDelivered      ShipmentStatus = "DELIVERED"
+	StatusException      ShipmentStatus = "EXCEPTION"
+	StatusReturned       ShipmentStatus = "RETURNED"
+)
+
+var knownStatuses = map[ShipmentStatus]bool{
+	StatusUnknown:        true,
+	StatusCreated:        true,
+	StatusInTransit:      true,
+	StatusOutForDelivery: true,
+	StatusAwaitingPickup: true,
+	StatusDelivered:      true,
+	StatusException:      true,
+	StatusReturned:       true,
+}
+
+func (s ShipmentStatus) Valid() bool {
+	return knownStatuses[s]
+}
+
+type Location struct {
+	City    string `json:"city,omitempty"`
+	State   string `json:"state,omitempty"`
+	Country string `json:"country,omitempty"`
+}
+
+type TrackingEvent struct {
+	Timestamp   time.Time      `json:"timestamp"`
+	Location    Location       `json:"location"`
+	Description string         `json:"description"`
+	Status      ShipmentStatus `json:"status,omitempty"`
+	RawStatus   string         `json:"raw_status,omitempty"`
+	EventCode   string         `json:"event_code,omitempty"`
+}
+
+// Key identifies an event across lookups of the same shipment. Status fields
+// are left out so that re-normalizing a phrase does not duplicate the event.
+func (e TrackingEvent) Key() string {
+	h := sha256.New()
+	for _, part := range []string{
+		e.Timestamp.UTC().Format(time.RFC3339),
+		strings.ToLower(strings.TrimSpace(e.Description)),
+		strings.ToLower(e.Location.City),
+		strings.ToLower(e.Location.State),
+		strings.ToLower(e.Location.Country),
+		e.EventCode,
+	} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type Shipment struct {
+	Provider     string          `json:"provider"`
+	TrackingCode string          `json:"tracking_code"`
+	Status       ShipmentStatus  `json:"status"`
+	RawStatus    string          `json:"raw_status"`
+	LastUpdate   time.Time       `json:"last_update"`
+	Events       []TrackingEvent `json:"events"`
+	Raw          json.RawMessage `json:"raw,omitempty"`
+}
+
+var ErrInvalidShipment = errors.New("invalid shipment")
+
+// Validate enforces the shipment schema that consumers of tracking_results
+// rely on. It is checked before a result is persisted.
+func (s Shipment) Validate() error {
+	var problems []string
+	if strings.TrimSpace(s.Provider) == "" {
+		problems = append(problems, "provider is required")
+	}
+	if strings.TrimSpace(s.TrackingCode) == "" {
+		problems = append(problems, "tracking_code is required")
+	}
+	if !s.Status.Valid() {
+		problems = append(problems, fmt.Sprintf("status %q is not a known status", s.Status))
+	}
+	if s.LastUpdate.IsZero() {
+		problems = append(problems, "last_update is required")
+	}
+	if s.Events == nil {
+		problems = append(problems, "events is required")
+	}
+	for i, evt := range s.Events {
+		if evt.Timestamp.IsZero() {
+			problems = append(problems, fmt.Sprintf("events[%d].timestamp is required", i))
+		}
+		if evt.Status != "" && !evt.Status.Valid() {
+			problems = append(problems, fmt.Sprintf("events[%d].status %q is not a known status", i, evt.Status))
+		}
+	}
+	if len(s.Raw) > 0 && !json.Valid(s.Raw) {
+		problems = append(problems, "raw must be valid JSON")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidShipment, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"02/01/2006 15:04:05",
+	"02/01/2006 15:04",
+	"02/01/2006",
+	"2006-01-02",
+}
+
+// ParseTimestamp accepts the timestamp formats carriers are known to emit.
+// Values without a zone are taken as UTC.
+func ParseTimestamp(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	for _, layout := range timestampLayouts {
+		if ts, err := time.Parse(layout, raw); err == nil {
+			return ts.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised timestamp %q", raw)
+}
+
+// ParseLocation splits carrier strings such as "SAO PAULO - SP",
+// "Curitiba/PR" or "Lisboa, LX, PT" into their parts. The second part is
+// always the state, since codes like "PR" are both states and countries; the
+// country comes from a third part or defaultCountry.
+func ParseLocation(raw, defaultCountry string) Location {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return Location{}
+	}
+
+	var parts []string
+	for _, sep := range []string{" - ", "/", ","} {
+		if strings.Contains(raw, sep) {
+			parts = strings.Split(raw, sep)
+			break
+		}
+	}
+	if parts == nil {
+		return Location{City: raw, Country: defaultCountry}
+	}
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+
+	loc := Location{City: parts[0], Country: defaultCountry}
+	if len(parts) > 1 {
+		loc.State = parts[1]
+	}
+	if len(parts) > 2 {
+		loc.Country = parts[2]
+	}
+	return loc
+}
@@ -0,0 +1,112 @@
+package providers
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func validShipment() Shipment {
+	ts := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	return Shipment{
+		Provider:     "dummy",
+		TrackingCode: "AA123",
+		Status:       StatusInTransit,
+		RawStatus:    "IN_TRANSIT",
+		LastUpdate:   ts,
+		Events: []TrackingEvent{
+			{Timestamp: ts, Location: Location{City: "SAO PAULO", State: "SP", Country: "BR"}, Description: "posted"},
+		},
+		Raw: json.RawMessage(`{"source":"dummy"}`),
+	}
+}
+
+func TestShipmentValidate(t *testing.T) {
+	if err := validShipment().Validate(); err != nil {
+		t.Fatalf("expected valid shipment, got %v", err)
+	}
+
+	invalid := validShipment()
+	invalid.Status = "ON_ITS_WAY"
+	invalid.Events[0].Timestamp = time.Time{}
+	invalid.Raw = json.RawMessage(`{`)
+	err := invalid.Validate()
+	if !errors.Is(err, ErrInvalidShipment) {
+		t.Fatalf("expected ErrInvalidShipment, got %v", err)
+	}
+
+	missing := validShipment()
+	missing.Events = nil
+	missing.LastUpdate = time.Time{}
+	if err := missing.Validate(); err == nil {
+		t.Fatalf("expected missing fields to fail validation")
+	}
+}
+
+func TestShipmentJSONShape(t *testing.T) {
+	data, err := json.Marshal(validShipment())
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if decoded["last_update"] != "2024-01-02T10:00:00Z" {
+		t.Fatalf("expected RFC 3339 last_update, got %v", decoded["last_update"])
+	}
+	events := decoded["events"].([]any)
+	location := events[0].(map[string]any)["location"].(map[string]any)
+	if location["city"] != "SAO PAULO" || location["state"] != "SP" {
+		t.Fatalf("unexpected location: %v", location)
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	want := time.Date(2024, 1, 2, 10, 30, 0, 0, time.UTC)
+	for _, raw := range []string{"2024-01-02T10:30:00Z", "2024-01-02 10:30:00", "02/01/2024 10:30", "2024-01-02T07:30:00-03:00"} {
+		got, err := ParseTimestamp(raw)
+		if err != nil {
+			t.Fatalf("parse %q: %v", raw, err)
+		}
+		if !got.Equal(want) {
+			t.Fatalf("parse %q: expected %s, got %s", raw, want, got)
+		}
+	}
+	if _, err := ParseTimestamp("yesterday"); err == nil {
+		t.Fatalf("expected error for unknown format")
+	}
+}
+
+func TestParseLocation(t *testing.T) {
+	cases := map[string]Location{
+		"SAO PAULO - SP":    {City: "SAO PAULO", State: "SP", Country: "BR"},
+		"Curitiba/PR":       {City: "Curitiba", State: "PR", Country: "BR"},
+		"Lisboa, LX, PT":    {City: "Lisboa", State: "LX", Country: "PT"},
+		"Centro de Triagem": {City: "Centro de Triagem", Country: "BR"},
+		"":                  {},
+	}
+	for raw, want := range cases {
+		if got := ParseLocation(raw, "BR"); got != want {
+			t.Fatalf("parse %q: expected %+v, got %+v", raw, want, got)
+		}
+	}
+}
+
+func TestTrackingEventKey(t *testing.T) {
+	a := validShipment().Events[0]
+	b := a
+	b.Description = "  POSTED "
+	a.Description = "posted"
+	b.Status = StatusCreated
+	b.Timestamp = a.Timestamp.In(time.FixedZone("BRT", -3*3600))
+	if a.Key() != b.Key() {
+		t.Fatalf("expected equivalent events to share a key")
+	}
+
+	b.Location.City = "CURITIBA"
+	if a.Key() == b.Key() {
+		t.Fatalf("expected different location to change the key")
+	}
+}
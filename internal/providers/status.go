@@ -0,0 +1,221 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// StatusTable maps carrier status phrases to the canonical enum. Keys are
+// written lower-case, without accents or punctuation and with single spaces;
+// incoming phrases are folded the same way, so "Objeto entregue ao
+// destinatário" and "OBJETO ENTREGUE AO DESTINATARIO" hit the same entry.
+type StatusTable map[string]ShipmentStatus
+
+// CommonStatusTable is consulted after a provider's own table. It covers the
+// enum names themselves plus phrases shared by Brazilian carriers.
+var CommonStatusTable = StatusTable{
+	"created":          StatusCreated,
+	"in transit":       StatusInTransit,
+	"out for delivery": StatusOutForDelivery,
+	"awaiting pickup":  StatusAwaitingPickup,
+	"delivered":        StatusDelivered,
+	"exception":        StatusException,
+	"returned":         StatusReturned,
+
+	"objeto postado":   StatusCreated,
+	"etiqueta emitida": StatusCreated,
+	"pedido criado":    StatusCreated,
+	"objeto postado apos o horario limite da unidade": StatusCreated,
+
+	"objeto em transito":      StatusInTransit,
+	"objeto em transferencia": StatusInTransit,
+	"objeto encaminhado":      StatusInTransit,
+	"em rota":                 StatusInTransit,
+	"objeto recebido na unidade de distribuicao": StatusInTransit,
+
+	"saiu para entrega":                        StatusOutForDelivery,
+	"em rota de entrega":                       StatusOutForDelivery,
+	"objeto saiu para entrega ao destinatario": StatusOutForDelivery,
+
+	"aguardando retirada":                             StatusAwaitingPickup,
+	"objeto aguardando retirada no endereco indicado": StatusAwaitingPickup,
+
+	"entregue":                        StatusDelivered,
+	"objeto entregue ao destinatario": StatusDelivered,
+
+	"objeto nao entregue":               StatusException,
+	"tentativa de entrega nao efetuada": StatusException,
+	"destinatario ausente":              StatusException,
+	"endereco incorreto":                StatusException,
+	"objeto extraviado":                 StatusException,
+
+	"devolvido ao remetente":           StatusReturned,
+	"objeto devolvido ao remetente":    StatusReturned,
+	"objeto em devolucao ao remetente": StatusReturned,
+}
+
+// fallbackRules classify phrases missing from every table by keyword, in
+// order, so the more specific rules win.
+var fallbackRules = []struct {
+	keywords []string
+	status   ShipmentStatus
+}{
+	{[]string{"devol", "return"}, StatusReturned},
+	{[]string{"saiu para entrega", "out for delivery", "rota de entrega"}, StatusOutForDelivery},
+	{[]string{"nao entregue", "nao efetuada", "ausente", "extravi", "falha", "fail", "exception", "incorret"}, StatusException},
+	{[]string{"entregue", "delivered"}, StatusDelivered},
+	{[]string{"retirada", "pickup", "retirar"}, StatusAwaitingPickup},
+	{[]string{"transit", "transfer", "encaminhad", "unidade", "rota"}, StatusInTransit},
+	{[]string{"postado", "postagem", "etiqueta", "criado", "created", "label"}, StatusCreated},
+}
+
+var accentReplacer = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e",
+	"í", "i", "ì", "i", "î", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c",
+)
+
+func statusKey(raw string) string {
+	key := accentReplacer.Replace(strings.ToLower(raw))
+	key = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return ' '
+	}, key)
+	return strings.Join(strings.Fields(key), " ")
+}
+
+// ClassifyStatus guesses a status for a phrase no table knows about.
+func ClassifyStatus(raw string) ShipmentStatus {
+	key := statusKey(raw)
+	for _, rule := range fallbackRules {
+		for _, kw := range rule.keywords {
+			if strings.Contains(key, kw) {
+				return rule.status
+			}
+		}
+	}
+	return StatusUnknown
+}
+
+// MapStatus looks raw up in table and then CommonStatusTable. When neither
+// knows it, the fallback classifier's guess is returned with mapped=false.
+func MapStatus(table StatusTable, raw string) (ShipmentStatus, bool) {
+	key := statusKey(raw)
+	if key == "" {
+		return StatusUnknown, false
+	}
+	if status, ok := table[key]; ok {
+		return status, true
+	}
+	if status, ok := CommonStatusTable[key]; ok {
+		return status, true
+	}
+	return ClassifyStatus(raw), false
+}
+
+type UnmappedRecorder interface {
+	RecordUnmapped(ctx context.Context, provider, rawStatus string, guess ShipmentStatus) error
+}
+
+// StatusNormalizer applies per-provider tables to shipments and reports
+// phrases that had to fall back to the classifier.
+type StatusNormalizer struct {
+	mu       sync.RWMutex
+	tables   map[string]StatusTable
+	recorder UnmappedRecorder
+}
+
+func NewStatusNormalizer(recorder UnmappedRecorder) *StatusNormalizer {
+	return &StatusNormalizer{tables: map[string]StatusTable{}, recorder: recorder}
+}
+
+func (n *StatusNormalizer) Register(provider string, table StatusTable) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.tables[provider] = table
+}
+
+func (n *StatusNormalizer) Normalize(ctx context.Context, provider, raw string) (ShipmentStatus, error) {
+	n.mu.RLock()
+	table := n.tables[provider]
+	n.mu.RUnlock()
+
+	status, mapped := MapStatus(table, raw)
+	if !mapped && statusKey(raw) != "" && n.recorder != nil {
+		if err := n.recorder.RecordUnmapped(ctx, provider, raw, status); err != nil {
+			return status, err
+		}
+	}
+	return status, nil
+}
+
+// Apply re-derives the shipment and event statuses from their raw values.
+// Events without a raw status are left as they are. Every status is applied
+// even when recording an unmapped phrase fails; those errors are joined.
+func (n *StatusNormalizer) Apply(ctx context.Context, shipment *Shipment) error {
+	var errs []error
+	status, err := n.Normalize(ctx, shipment.Provider, shipment.RawStatus)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	shipment.Status = status
+
+	for i := range shipment.Events {
+		if shipment.Events[i].RawStatus == "" {
+			continue
+		}
+		status, err := n.Normalize(ctx, shipment.Provider, shipment.Events[i].RawStatus)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		shipment.Events[i].Status = status
+	}
+	return errors.Join(errs...)
+}
+
+// StatusMapper is implemented by providers that bring their own StatusTable.
+type StatusMapper interface {
+	StatusTable() StatusTable
+}
+
+// WithStatusNormalizer wraps p so every result it returns goes through n.
+// Recording unmapped phrases is bookkeeping: a failure is passed to onError
+// and the lookup still succeeds.
+func WithStatusNormalizer(p Provider, n *StatusNormalizer, onError func(error)) Provider {
+	if mapper, ok := p.(StatusMapper); ok {
+		n.Register(p.Name(), mapper.StatusTable())
+	}
+	return &normalizedProvider{Provider: p, normalizer: n, onError: onError}
+}
+
+type normalizedProvider struct {
+	Provider
+	normalizer *StatusNormalizer
+	onError    func(error)
+}
+
+func (p *normalizedProvider) Track(ctx context.Context, trackingCode string) (Result, error) {
+	result, err := p.Provider.Track(ctx, trackingCode)
+	if err != nil {
+		return result, err
+	}
+	if err := p.normalizer.Apply(ctx, &result.Payload); err != nil && p.onError != nil {
+		p.onError(fmt.Errorf("record unmapped status: %w", err))
+	}
+	return result, nil
+}
+
+func (p *normalizedProvider) Health(ctx context.Context) error {
+	if checker, ok := p.Provider.(HealthChecker); ok {
+		return checker.Health(ctx)
+	}
+	return nil
+}
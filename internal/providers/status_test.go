@@ -0,0 +1,81 @@
+package providers
+
+import (
+	"context"
+	"testing"
+)
+
+type recordedStatus struct {
+	provider string
+	raw      string
+	guess    ShipmentStatus
+}
+
+type memoryRecorder struct {
+	records []recordedStatus
+}
+
+func (m *memoryRecorder) RecordUnmapped(ctx context.Context, provider, rawStatus string, guess ShipmentStatus) error {
+	m.records = append(m.records, recordedStatus{provider, rawStatus, guess})
+	return nil
+}
+
+func TestStatusTableKeysAreNormalized(t *testing.T) {
+	for phrase, status := range CommonStatusTable {
+		if statusKey(phrase) != phrase {
+			t.Fatalf("key %q is not in normalized form", phrase)
+		}
+		if !status.Valid() {
+			t.Fatalf("key %q maps to unknown status %s", phrase, status)
+		}
+	}
+}
+
+func TestMapStatus(t *testing.T) {
+	table := StatusTable{"em preparacao": StatusCreated}
+	cases := []struct {
+		raw    string
+		want   ShipmentStatus
+		mapped bool
+	}{
+		{"IN_TRANSIT", StatusInTransit, true},
+		{"Objeto entregue ao destinatário", StatusDelivered, true},
+		{"OBJETO SAIU PARA ENTREGA AO DESTINATÁRIO", StatusOutForDelivery, true},
+		{"Em preparação", StatusCreated, true},
+		{"Objeto devolvido ao remetente.", StatusReturned, true},
+		{"Objeto não entregue - carteiro não atendido", StatusException, false},
+		{"Objeto entregue na caixa de correio", StatusDelivered, false},
+		{"Aguardando pagamento", StatusUnknown, false},
+		{"", StatusUnknown, false},
+	}
+	for _, tc := range cases {
+		got, mapped := MapStatus(table, tc.raw)
+		if got != tc.want || mapped != tc.mapped {
+			t.Fatalf("map %q: expected %s/%v, got %s/%v", tc.raw, tc.want, tc.mapped, got, mapped)
+		}
+	}
+}
+
+func TestStatusNormalizerRecordsUnmapped(t *testing.T) {
+	recorder := &memoryRecorder{}
+	normalizer := NewStatusNormalizer(recorder)
+	normalizer.Register("carrier", StatusTable{"coletado": StatusInTransit})
+
+	shipment := validShipment()
+	shipment.Provider = "carrier"
+	shipment.RawStatus = "Coletado"
+	shipment.Events[0].RawStatus = "Encaminhado para unidade X"
+
+	if err := normalizer.Apply(context.Background(), &shipment); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if shipment.Status != StatusInTransit {
+		t.Fatalf("expected provider table to map status, got %s", shipment.Status)
+	}
+	if shipment.Events[0].Status != StatusInTransit {
+		t.Fatalf("expected classifier to guess IN_TRANSIT, got %s", shipment.Events[0].Status)
+	}
+	if len(recorder.records) != 1 || recorder.records[0].raw != "Encaminhado para unidade X" {
+		t.Fatalf("expected unmapped event status to be recorded, got %+v", recorder.records)
+	}
+}
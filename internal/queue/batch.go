@@ -0,0 +1,39 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type StreamMessage struct {
+	Stream string
+	Values map[string]any
+}
+
+// AddJobs XADDs msgs in a single pipeline. It returns the ids of the leading
+// messages that were added; on error, the message at len(ids) and everything
+// after it should be treated as not sent.
+func (c *Client) AddJobs(ctx context.Context, msgs []StreamMessage) ([]string, error) {
+	if len(msgs) == 0 {
+		return nil, nil
+	}
+
+	pipe := c.redis.Pipeline()
+	cmds := make([]*redis.StringCmd, len(msgs))
+	for i, msg := range msgs {
+		cmds[i] = pipe.XAdd(ctx, &redis.XAddArgs{Stream: msg.Stream, Values: msg.Values})
+	}
+	_, _ = pipe.Exec(ctx)
+
+	ids := make([]string, 0, len(msgs))
+	for _, cmd := range cmds {
+		id, err := cmd.Result()
+		if err != nil {
+			return ids, fmt.Errorf("xadd: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
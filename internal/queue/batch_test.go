@@ -0,0 +1,48 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestAddJobs(t *testing.T) {
+	mini, err := miniredis.Run()
+	if err != nil {
+		t.Skipf("miniredis unavailable: %v", err)
+	}
+	defer mini.Close()
+
+	client := New(mini.Addr())
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if ids, err := client.AddJobs(ctx, nil); err != nil || len(ids) != 0 {
+		t.Fatalf("expected empty batch to be a no-op, got %v %v", ids, err)
+	}
+
+	var msgs []StreamMessage
+	for i := 0; i < 50; i++ {
+		msgs = append(msgs, StreamMessage{Stream: "tracking:jobs", Values: map[string]any{"trackingCode": fmt.Sprintf("AA%d", i)}})
+	}
+	ids, err := client.AddJobs(ctx, msgs)
+	if err != nil {
+		t.Fatalf("add jobs: %v", err)
+	}
+	if len(ids) != len(msgs) {
+		t.Fatalf("expected %d ids, got %d", len(msgs), len(ids))
+	}
+
+	entries, err := mini.Stream("tracking:jobs")
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	if len(entries) != len(msgs) || entries[0].Values[1] != "AA0" || entries[49].Values[1] != "AA49" {
+		t.Fatalf("expected messages in order, got %d entries", len(entries))
+	}
+}
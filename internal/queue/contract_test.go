@@ -0,0 +1,330 @@
+package queue
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// The contract below runs against every backend. The Postgres run needs a
+// database with the migrations applied, named by QUEUE_TEST_DB_URL.
+
+func TestRedisContract(t *testing.T) {
+	testContract(t, func(t *testing.T) Queue {
+		mini, err := miniredis.Run()
+		if err != nil {
+			t.Skipf("miniredis unavailable: %v", err)
+		}
+		t.Cleanup(mini.Close)
+		return New(mini.Addr())
+	})
+}
+
+func TestMemoryContract(t *testing.T) {
+	testContract(t, func(t *testing.T) Queue {
+		return NewMemory()
+	})
+}
+
+func TestPostgresContract(t *testing.T) {
+	url := os.Getenv("QUEUE_TEST_DB_URL")
+	if url == "" {
+		t.Skip("QUEUE_TEST_DB_URL not set")
+	}
+	pool, err := pgxpool.New(context.Background(), url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	testContract(t, func(t *testing.T) Queue {
+		return NewPostgres(pool)
+	})
+}
+
+func testContract(t *testing.T, newQueue func(t *testing.T) Queue) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, ctx context.Context, q Queue, stream string)
+	}{
+		{"ReadAndAck", contractReadAndAck},
+		{"GroupStartsAtNewMessages", contractGroupStartsAtNewMessages},
+		{"ReadOrderAndCount", contractReadOrderAndCount},
+		{"GroupsAreIndependent", contractGroupsAreIndependent},
+		{"ReclaimIdle", contractReclaimIdle},
+		{"BlockingReadWakes", contractBlockingReadWakes},
+		{"BlockingReadTimesOut", contractBlockingReadTimesOut},
+		{"AddJobs", contractAddJobs},
+		{"ReadUnknownGroupFails", contractReadUnknownGroupFails},
+		{"Outstanding", contractOutstanding},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			q := newQueue(t)
+			defer q.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			tc.run(t, ctx, q, "test:"+strings.ToLower(tc.name)+":"+uuid.NewString())
+		})
+	}
+}
+
+func mustEnsureGroup(t *testing.T, ctx context.Context, q Queue, stream, group string) {
+	t.Helper()
+	if err := q.EnsureGroup(ctx, stream, group); err != nil {
+		t.Fatalf("ensure group: %v", err)
+	}
+}
+
+func mustAdd(t *testing.T, ctx context.Context, q Queue, stream string, values map[string]any) string {
+	t.Helper()
+	id, err := q.AddJob(ctx, stream, values)
+	if err != nil {
+		t.Fatalf("add job: %v", err)
+	}
+	if id == "" {
+		t.Fatal("expected id")
+	}
+	return id
+}
+
+func mustRead(t *testing.T, ctx context.Context, q Queue, stream, group, consumer string, count int64) []Message {
+	t.Helper()
+	msgs, err := q.ReadGroup(ctx, stream, group, consumer, count, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("read group: %v", err)
+	}
+	return msgs
+}
+
+func contractReadAndAck(t *testing.T, ctx context.Context, q Queue, stream string) {
+	mustEnsureGroup(t, ctx, q, stream, "workers")
+	mustEnsureGroup(t, ctx, q, stream, "workers")
+	id := mustAdd(t, ctx, q, stream, map[string]any{"jobId": "job-1", "attempt": 2})
+
+	msgs := mustRead(t, ctx, q, stream, "workers", "worker-1", 10)
+	if len(msgs) != 1 || msgs[0].ID != id {
+		t.Fatalf("expected message %s, got %+v", id, msgs)
+	}
+	if msgs[0].Values["jobId"] != "job-1" || msgs[0].Values["attempt"] != "2" {
+		t.Fatalf("expected string values, got %#v", msgs[0].Values)
+	}
+	if again := mustRead(t, ctx, q, stream, "workers", "worker-2", 10); len(again) != 0 {
+		t.Fatalf("message delivered twice: %+v", again)
+	}
+
+	if err := q.Ack(ctx, stream, "workers", id); err != nil {
+		t.Fatalf("ack: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	deliveries, err := q.Reclaim(ctx, stream, "workers", "worker-2", time.Millisecond, 10)
+	if err != nil {
+		t.Fatalf("reclaim: %v", err)
+	}
+	if len(deliveries) != 0 {
+		t.Fatalf("acked message reclaimed: %+v", deliveries)
+	}
+}
+
+func contractGroupStartsAtNewMessages(t *testing.T, ctx context.Context, q Queue, stream string) {
+	mustEnsureGroup(t, ctx, q, stream, "early")
+	mustAdd(t, ctx, q, stream, map[string]any{"n": "1"})
+	mustEnsureGroup(t, ctx, q, stream, "late")
+	id := mustAdd(t, ctx, q, stream, map[string]any{"n": "2"})
+
+	msgs := mustRead(t, ctx, q, stream, "late", "worker-1", 10)
+	if len(msgs) != 1 || msgs[0].ID != id {
+		t.Fatalf("expected only the message added after the group, got %+v", msgs)
+	}
+}
+
+func contractReadOrderAndCount(t *testing.T, ctx context.Context, q Queue, stream string) {
+	mustEnsureGroup(t, ctx, q, stream, "workers")
+	ids := []string{
+		mustAdd(t, ctx, q, stream, map[string]any{"n": "1"}),
+		mustAdd(t, ctx, q, stream, map[string]any{"n": "2"}),
+		mustAdd(t, ctx, q, stream, map[string]any{"n": "3"}),
+	}
+
+	first := mustRead(t, ctx, q, stream, "workers", "worker-1", 2)
+	if len(first) != 2 || first[0].ID != ids[0] || first[1].ID != ids[1] {
+		t.Fatalf("expected %v, got %+v", ids[:2], first)
+	}
+	rest := mustRead(t, ctx, q, stream, "workers", "worker-2", 2)
+	if len(rest) != 1 || rest[0].ID != ids[2] {
+		t.Fatalf("expected %s, got %+v", ids[2], rest)
+	}
+}
+
+func contractGroupsAreIndependent(t *testing.T, ctx context.Context, q Queue, stream string) {
+	mustEnsureGroup(t, ctx, q, stream, "a")
+	mustEnsureGroup(t, ctx, q, stream, "b")
+	id := mustAdd(t, ctx, q, stream, map[string]any{"n": "1"})
+
+	for _, group := range []string{"a", "b"} {
+		msgs := mustRead(t, ctx, q, stream, group, "worker-1", 10)
+		if len(msgs) != 1 || msgs[0].ID != id {
+			t.Fatalf("group %s: expected %s, got %+v", group, id, msgs)
+		}
+	}
+	if err := q.Ack(ctx, stream, "a", id); err != nil {
+		t.Fatalf("ack: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	deliveries, err := q.Reclaim(ctx, stream, "b", "worker-2", time.Millisecond, 10)
+	if err != nil {
+		t.Fatalf("reclaim: %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Message.Values["n"] != "1" {
+		t.Fatalf("ack in group a affected group b: %+v", deliveries)
+	}
+}
+
+func contractReclaimIdle(t *testing.T, ctx context.Context, q Queue, stream string) {
+	mustEnsureGroup(t, ctx, q, stream, "workers")
+	id := mustAdd(t, ctx, q, stream, map[string]any{"jobId": "job-1"})
+	mustRead(t, ctx, q, stream, "workers", "worker-1", 1)
+
+	deliveries, err := q.Reclaim(ctx, stream, "workers", "worker-2", time.Minute, 10)
+	if err != nil {
+		t.Fatalf("reclaim: %v", err)
+	}
+	if len(deliveries) != 0 {
+		t.Fatalf("expected no deliveries before idle threshold, got %d", len(deliveries))
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	deliveries, err = q.Reclaim(ctx, stream, "workers", "worker-2", 20*time.Millisecond, 10)
+	if err != nil {
+		t.Fatalf("reclaim: %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Message.ID != id {
+		t.Fatalf("expected %s reclaimed, got %+v", id, deliveries)
+	}
+	if deliveries[0].DeliveryCount != 2 {
+		t.Fatalf("expected delivery count 2, got %d", deliveries[0].DeliveryCount)
+	}
+	if deliveries[0].Message.Values["jobId"] != "job-1" {
+		t.Fatalf("unexpected values %#v", deliveries[0].Message.Values)
+	}
+	if err := q.Ack(ctx, stream, "workers", id); err != nil {
+		t.Fatalf("ack: %v", err)
+	}
+}
+
+func contractBlockingReadWakes(t *testing.T, ctx context.Context, q Queue, stream string) {
+	mustEnsureGroup(t, ctx, q, stream, "workers")
+
+	type result struct {
+		msgs []Message
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		msgs, err := q.ReadGroup(ctx, stream, "workers", "worker-1", 1, 3*time.Second)
+		done <- result{msgs, err}
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	start := time.Now()
+	id := mustAdd(t, ctx, q, stream, map[string]any{"n": "1"})
+
+	select {
+	case res := <-done:
+		if res.err != nil {
+			t.Fatalf("read group: %v", res.err)
+		}
+		if len(res.msgs) != 1 || res.msgs[0].ID != id {
+			t.Fatalf("expected %s, got %+v", id, res.msgs)
+		}
+		if waited := time.Since(start); waited > 2*time.Second {
+			t.Fatalf("blocked read woke after %s", waited)
+		}
+	case <-ctx.Done():
+		t.Fatal("blocked read never returned")
+	}
+}
+
+func contractBlockingReadTimesOut(t *testing.T, ctx context.Context, q Queue, stream string) {
+	mustEnsureGroup(t, ctx, q, stream, "workers")
+
+	start := time.Now()
+	msgs, err := q.ReadGroup(ctx, stream, "workers", "worker-1", 1, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("read group: %v", err)
+	}
+	if len(msgs) != 0 {
+		t.Fatalf("expected no messages, got %+v", msgs)
+	}
+	if waited := time.Since(start); waited < 40*time.Millisecond {
+		t.Fatalf("read returned after %s, expected to block", waited)
+	}
+}
+
+func contractAddJobs(t *testing.T, ctx context.Context, q Queue, stream string) {
+	mustEnsureGroup(t, ctx, q, stream, "workers")
+	ids, err := q.AddJobs(ctx, []StreamMessage{
+		{Stream: stream, Values: map[string]any{"n": "1"}},
+		{Stream: stream, Values: map[string]any{"n": "2"}},
+	})
+	if err != nil {
+		t.Fatalf("add jobs: %v", err)
+	}
+	if len(ids) != 2 {
+		t.Fatalf("expected 2 ids, got %v", ids)
+	}
+
+	msgs := mustRead(t, ctx, q, stream, "workers", "worker-1", 10)
+	if len(msgs) != 2 || msgs[0].ID != ids[0] || msgs[1].ID != ids[1] {
+		t.Fatalf("expected %v, got %+v", ids, msgs)
+	}
+}
+
+func contractReadUnknownGroupFails(t *testing.T, ctx context.Context, q Queue, stream string) {
+	mustEnsureGroup(t, ctx, q, stream, "workers")
+	mustAdd(t, ctx, q, stream, map[string]any{"n": "1"})
+
+	start := time.Now()
+	msgs, err := q.ReadGroup(ctx, stream, "missing", "worker-1", 1, time.Second)
+	if err == nil {
+		t.Fatalf("expected an error for an unknown group, got %+v", msgs)
+	}
+	if waited := time.Since(start); waited > 500*time.Millisecond {
+		t.Fatalf("read blocked for %s before failing", waited)
+	}
+}
+
+func contractOutstanding(t *testing.T, ctx context.Context, q Queue, stream string) {
+	mustEnsureGroup(t, ctx, q, stream, "workers")
+	acked := mustAdd(t, ctx, q, stream, map[string]any{"n": "1"})
+	pending := mustAdd(t, ctx, q, stream, map[string]any{"n": "2"})
+	unread := mustAdd(t, ctx, q, stream, map[string]any{"n": "3"})
+
+	mustRead(t, ctx, q, stream, "workers", "worker-1", 2)
+	if err := q.Ack(ctx, stream, "workers", acked); err != nil {
+		t.Fatalf("ack: %v", err)
+	}
+
+	outstanding, err := q.Outstanding(ctx, stream, "workers", acked, pending, unread)
+	if err != nil {
+		t.Fatalf("outstanding: %v", err)
+	}
+	if outstanding[acked] || !outstanding[pending] || !outstanding[unread] {
+		t.Fatalf("expected only %s and %s outstanding, got %v", pending, unread, outstanding)
+	}
+
+	outstanding, err = q.Outstanding(ctx, stream, "missing", pending)
+	if err != nil {
+		t.Fatalf("outstanding for unknown group: %v", err)
+	}
+	if len(outstanding) != 0 {
+		t.Fatalf("expected nothing outstanding for an unknown group, got %v", outstanding)
+	}
+}
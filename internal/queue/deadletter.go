@@ -0,0 +1,216 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	ReasonInvalidMessage = "INVALID_MESSAGE"
+	ReasonMaxDeliveries  = "MAX_DELIVERIES_EXCEEDED"
+)
+
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// maxWatchRetries bounds how often a WATCH transaction on the dlq is retried
+// when another client writes to it first.
+const maxWatchRetries = 5
+
+type DeadLetter struct {
+	ID            string         `json:"id"`
+	Stream        string         `json:"stream"`
+	OriginalID    string         `json:"original_id"`
+	Values        map[string]any `json:"values"`
+	Reason        string         `json:"reason"`
+	Consumer      string         `json:"consumer"`
+	DeliveryCount int64          `json:"delivery_count"`
+	FailedAt      time.Time      `json:"failed_at"`
+}
+
+// DeadLetter copies entry into the dlq stream and, when group is set, acks the
+// original message on q, the job queue it was read from; nil means c. On c
+// both happen in one transaction. Other backends are acked after the copy, so
+// a failed ack leaves the message pending to be dead-lettered again rather
+// than lost.
+func (c *Client) DeadLetter(ctx context.Context, q Queue, group, dlq string, entry DeadLetter) (string, error) {
+	if entry.FailedAt.IsZero() {
+		entry.FailedAt = time.Now().UTC()
+	}
+	payload, err := json.Marshal(entry.Values)
+	if err != nil {
+		return "", fmt.Errorf("marshal dead letter values: %w", err)
+	}
+	ack := group != "" && entry.Stream != "" && entry.OriginalID != ""
+
+	pipe := c.redis.TxPipeline()
+	add := pipe.XAdd(ctx, &redis.XAddArgs{
+		Stream: dlq,
+		Values: map[string]any{
+			"stream":        entry.Stream,
+			"originalId":    entry.OriginalID,
+			"values":        string(payload),
+			"reason":        entry.Reason,
+			"consumer":      entry.Consumer,
+			"deliveryCount": entry.DeliveryCount,
+			"failedAt":      entry.FailedAt.Format(time.RFC3339Nano),
+		},
+	})
+	if ack && c.owns(q) {
+		pipe.XAck(ctx, entry.Stream, group, entry.OriginalID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", fmt.Errorf("dead letter: %w", err)
+	}
+	if ack && !c.owns(q) {
+		if err := q.Ack(ctx, entry.Stream, group, entry.OriginalID); err != nil {
+			return add.Val(), fmt.Errorf("dead letter: %w", err)
+		}
+	}
+	return add.Val(), nil
+}
+
+// ListDeadLetters returns up to count entries with IDs after cursor, oldest
+// first. Pass "" to start from the beginning and the last returned ID to page.
+func (c *Client) ListDeadLetters(ctx context.Context, dlq, cursor string, count int64) ([]DeadLetter, error) {
+	start := "-"
+	if cursor != "" {
+		start = "(" + cursor
+	}
+	msgs, err := c.redis.XRangeN(ctx, dlq, start, "+", count).Result()
+	if err != nil {
+		return nil, fmt.Errorf("xrange: %w", err)
+	}
+	letters := make([]DeadLetter, 0, len(msgs))
+	for _, msg := range msgs {
+		letter, err := parseDeadLetter(msg)
+		if err != nil {
+			return nil, err
+		}
+		letters = append(letters, letter)
+	}
+	return letters, nil
+}
+
+func (c *Client) GetDeadLetter(ctx context.Context, dlq, id string) (DeadLetter, error) {
+	msgs, err := c.redis.XRange(ctx, dlq, id, id).Result()
+	if err != nil {
+		return DeadLetter{}, fmt.Errorf("xrange: %w", err)
+	}
+	if len(msgs) == 0 {
+		return DeadLetter{}, ErrDeadLetterNotFound
+	}
+	return parseDeadLetter(msgs[0])
+}
+
+// RequeueDeadLetter re-adds the original fields to the source stream on q,
+// the job queue (nil means c), and removes the entry from the dlq. It returns
+// the new stream message ID. The dlq is watched from the read to the write,
+// so of two concurrent requeues of one entry only one re-adds it; the other
+// gets ErrDeadLetterNotFound. On other backends the entry is taken off the
+// dlq first and put back if the add fails.
+func (c *Client) RequeueDeadLetter(ctx context.Context, q Queue, dlq, id string) (string, error) {
+	direct := c.owns(q)
+	for i := 0; i < maxWatchRetries; i++ {
+		var letter DeadLetter
+		var add *redis.StringCmd
+		err := c.redis.Watch(ctx, func(tx *redis.Tx) error {
+			msgs, err := tx.XRange(ctx, dlq, id, id).Result()
+			if err != nil {
+				return fmt.Errorf("xrange: %w", err)
+			}
+			if len(msgs) == 0 {
+				return ErrDeadLetterNotFound
+			}
+			letter, err = parseDeadLetter(msgs[0])
+			if err != nil {
+				return err
+			}
+			if letter.Stream == "" {
+				return fmt.Errorf("dead letter %s has no source stream", id)
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				if direct {
+					add = pipe.XAdd(ctx, &redis.XAddArgs{Stream: letter.Stream, Values: letter.Values})
+				}
+				pipe.XDel(ctx, dlq, id)
+				return nil
+			})
+			return err
+		}, dlq)
+		switch {
+		case errors.Is(err, redis.TxFailedErr):
+			// The dlq changed under us; look again.
+			continue
+		case errors.Is(err, ErrDeadLetterNotFound):
+			return "", err
+		case err != nil:
+			return "", fmt.Errorf("requeue dead letter: %w", err)
+		}
+		if direct {
+			return add.Val(), nil
+		}
+
+		newID, err := q.AddJob(ctx, letter.Stream, letter.Values)
+		if err != nil {
+			if _, putErr := c.DeadLetter(ctx, nil, "", dlq, letter); putErr != nil {
+				return "", errors.Join(fmt.Errorf("requeue dead letter: %w", err), putErr)
+			}
+			return "", fmt.Errorf("requeue dead letter: %w", err)
+		}
+		return newID, nil
+	}
+	return "", fmt.Errorf("requeue dead letter %s: dlq kept changing", id)
+}
+
+func (c *Client) DeleteDeadLetters(ctx context.Context, dlq string, ids ...string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	n, err := c.redis.XDel(ctx, dlq, ids...).Result()
+	if err != nil {
+		return 0, fmt.Errorf("xdel: %w", err)
+	}
+	return n, nil
+}
+
+// PurgeDeadLetters drops every dlq entry added before the given time.
+func (c *Client) PurgeDeadLetters(ctx context.Context, dlq string, before time.Time) (int64, error) {
+	n, err := c.redis.XTrimMinID(ctx, dlq, fmt.Sprintf("%d-0", before.UnixMilli())).Result()
+	if err != nil {
+		return 0, fmt.Errorf("xtrim: %w", err)
+	}
+	return n, nil
+}
+
+func parseDeadLetter(msg redis.XMessage) (DeadLetter, error) {
+	letter := DeadLetter{
+		ID:         msg.ID,
+		Stream:     stringValue(msg.Values["stream"]),
+		OriginalID: stringValue(msg.Values["originalId"]),
+		Reason:     stringValue(msg.Values["reason"]),
+		Consumer:   stringValue(msg.Values["consumer"]),
+	}
+	if raw := stringValue(msg.Values["values"]); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &letter.Values); err != nil {
+			return DeadLetter{}, fmt.Errorf("parse dead letter %s: %w", msg.ID, err)
+		}
+	}
+	if raw := stringValue(msg.Values["deliveryCount"]); raw != "" {
+		letter.DeliveryCount, _ = strconv.ParseInt(raw, 10, 64)
+	}
+	if raw := stringValue(msg.Values["failedAt"]); raw != "" {
+		letter.FailedAt, _ = time.Parse(time.RFC3339Nano, raw)
+	}
+	return letter, nil
+}
+
+func stringValue(v any) string {
+	s, _ := v.(string)
+	return s
+}
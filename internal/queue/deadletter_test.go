@@ -0,0 +1,233 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestDeadLetterLifecycle(t *testing.T) {
+	mini, err := miniredis.Run()
+	if err != nil {
+		t.Skipf("miniredis unavailable: %v", err)
+	}
+	defer mini.Close()
+
+	client := New(mini.Addr())
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := client.EnsureGroup(ctx, "tracking:jobs", "tracking-workers"); err != nil {
+		t.Fatalf("ensure group: %v", err)
+	}
+	if _, err := client.AddJob(ctx, "tracking:jobs", map[string]any{"jobId": "not-a-uuid"}); err != nil {
+		t.Fatalf("add job: %v", err)
+	}
+	messages, err := client.ReadGroup(ctx, "tracking:jobs", "tracking-workers", "worker-1", 1, 10*time.Millisecond)
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("read group: %v (%d messages)", err, len(messages))
+	}
+
+	dlqID, err := client.DeadLetter(ctx, nil, "tracking-workers", "tracking:jobs:dlq", DeadLetter{
+		Stream:        "tracking:jobs",
+		OriginalID:    messages[0].ID,
+		Values:        messages[0].Values,
+		Reason:        ReasonInvalidMessage,
+		Consumer:      "worker-1",
+		DeliveryCount: 1,
+	})
+	if err != nil {
+		t.Fatalf("dead letter: %v", err)
+	}
+
+	pending, err := client.Pending(ctx, "tracking:jobs", "tracking-workers", 0, 10)
+	if err != nil {
+		t.Fatalf("pending: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Fatalf("expected original message to be acked, got %d pending", len(pending))
+	}
+
+	letters, err := client.ListDeadLetters(ctx, "tracking:jobs:dlq", "", 10)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(letters) != 1 {
+		t.Fatalf("expected 1 dead letter, got %d", len(letters))
+	}
+	letter := letters[0]
+	if letter.ID != dlqID || letter.Reason != ReasonInvalidMessage || letter.Consumer != "worker-1" {
+		t.Fatalf("unexpected dead letter: %+v", letter)
+	}
+	if letter.Values["jobId"] != "not-a-uuid" {
+		t.Fatalf("expected original values, got %v", letter.Values)
+	}
+	if letter.DeliveryCount != 1 || letter.FailedAt.IsZero() {
+		t.Fatalf("expected delivery metadata, got %+v", letter)
+	}
+
+	if _, err := client.RequeueDeadLetter(ctx, nil, "tracking:jobs:dlq", dlqID); err != nil {
+		t.Fatalf("requeue: %v", err)
+	}
+	if _, err := client.GetDeadLetter(ctx, "tracking:jobs:dlq", dlqID); err != ErrDeadLetterNotFound {
+		t.Fatalf("expected ErrDeadLetterNotFound, got %v", err)
+	}
+	messages, err = client.ReadGroup(ctx, "tracking:jobs", "tracking-workers", "worker-1", 1, 10*time.Millisecond)
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("expected requeued message: %v (%d messages)", err, len(messages))
+	}
+	if messages[0].Values["jobId"] != "not-a-uuid" {
+		t.Fatalf("unexpected requeued values: %v", messages[0].Values)
+	}
+}
+
+func TestPurgeDeadLetters(t *testing.T) {
+	mini, err := miniredis.Run()
+	if err != nil {
+		t.Skipf("miniredis unavailable: %v", err)
+	}
+	defer mini.Close()
+
+	client := New(mini.Addr())
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	mini.SetTime(now.Add(-time.Hour))
+	if _, err := client.DeadLetter(ctx, nil, "", "tracking:jobs:dlq", DeadLetter{Reason: ReasonMaxDeliveries}); err != nil {
+		t.Fatalf("dead letter: %v", err)
+	}
+	mini.SetTime(now)
+	if _, err := client.DeadLetter(ctx, nil, "", "tracking:jobs:dlq", DeadLetter{Reason: ReasonMaxDeliveries}); err != nil {
+		t.Fatalf("dead letter: %v", err)
+	}
+
+	if _, err := client.PurgeDeadLetters(ctx, "tracking:jobs:dlq", now.Add(-time.Minute)); err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	letters, err := client.ListDeadLetters(ctx, "tracking:jobs:dlq", "", 10)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(letters) != 1 {
+		t.Fatalf("expected 1 dead letter after purge, got %d", len(letters))
+	}
+}
+
+func TestRequeueDeadLetterOnce(t *testing.T) {
+	mini, err := miniredis.Run()
+	if err != nil {
+		t.Skipf("miniredis unavailable: %v", err)
+	}
+	defer mini.Close()
+
+	client := New(mini.Addr())
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := client.EnsureGroup(ctx, "tracking:jobs", "tracking-workers"); err != nil {
+		t.Fatalf("ensure group: %v", err)
+	}
+	dlqID, err := client.DeadLetter(ctx, nil, "", "tracking:jobs:dlq", DeadLetter{
+		Stream: "tracking:jobs",
+		Values: map[string]any{"jobId": "job-1"},
+		Reason: ReasonMaxDeliveries,
+	})
+	if err != nil {
+		t.Fatalf("dead letter: %v", err)
+	}
+
+	const requeuers = 32
+	errs := make(chan error, requeuers)
+	for i := 0; i < requeuers; i++ {
+		go func() {
+			_, err := client.RequeueDeadLetter(ctx, nil, "tracking:jobs:dlq", dlqID)
+			errs <- err
+		}()
+	}
+	requeued := 0
+	for i := 0; i < requeuers; i++ {
+		err := <-errs
+		switch {
+		case err == nil:
+			requeued++
+		case !errors.Is(err, ErrDeadLetterNotFound):
+			t.Fatalf("requeue: %v", err)
+		}
+	}
+	if requeued != 1 {
+		t.Fatalf("expected exactly one requeue, got %d", requeued)
+	}
+
+	messages, err := client.ReadGroup(ctx, "tracking:jobs", "tracking-workers", "worker-1", 10, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("read group: %v", err)
+	}
+	if len(messages) != 1 {
+		t.Fatalf("expected one requeued message, got %d", len(messages))
+	}
+}
+
+func TestDeadLetterOtherBackend(t *testing.T) {
+	mini, err := miniredis.Run()
+	if err != nil {
+		t.Skipf("miniredis unavailable: %v", err)
+	}
+	defer mini.Close()
+
+	client := New(mini.Addr())
+	defer client.Close()
+	jobs := NewMemory()
+	defer jobs.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := jobs.EnsureGroup(ctx, "tracking:jobs", "tracking-workers"); err != nil {
+		t.Fatalf("ensure group: %v", err)
+	}
+	if _, err := jobs.AddJob(ctx, "tracking:jobs", map[string]any{"jobId": "not-a-uuid"}); err != nil {
+		t.Fatalf("add job: %v", err)
+	}
+	messages, err := jobs.ReadGroup(ctx, "tracking:jobs", "tracking-workers", "worker-1", 1, -1)
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("read group: %v (%d messages)", err, len(messages))
+	}
+
+	dlqID, err := client.DeadLetter(ctx, jobs, "tracking-workers", "tracking:jobs:dlq", DeadLetter{
+		Stream:     "tracking:jobs",
+		OriginalID: messages[0].ID,
+		Values:     messages[0].Values,
+		Reason:     ReasonInvalidMessage,
+	})
+	if err != nil {
+		t.Fatalf("dead letter: %v", err)
+	}
+	deliveries, err := jobs.Reclaim(ctx, "tracking:jobs", "tracking-workers", "worker-2", 0, 10)
+	if err != nil {
+		t.Fatalf("reclaim: %v", err)
+	}
+	if len(deliveries) != 0 {
+		t.Fatalf("expected the original message to be acked, got %+v", deliveries)
+	}
+
+	if _, err := client.RequeueDeadLetter(ctx, jobs, "tracking:jobs:dlq", dlqID); err != nil {
+		t.Fatalf("requeue: %v", err)
+	}
+	if _, err := client.GetDeadLetter(ctx, "tracking:jobs:dlq", dlqID); err != ErrDeadLetterNotFound {
+		t.Fatalf("expected ErrDeadLetterNotFound, got %v", err)
+	}
+	messages, err = jobs.ReadGroup(ctx, "tracking:jobs", "tracking-workers", "worker-1", 1, -1)
+	if err != nil || len(messages) != 1 || messages[0].Values["jobId"] != "not-a-uuid" {
+		t.Fatalf("expected requeued message: %v (%+v)", err, messages)
+	}
+}
@@ -0,0 +1,330 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrClosed is returned by a Memory queue after Close.
+var ErrClosed = errors.New("queue closed")
+
+// Memory is an in-process Queue for running the API and worker in one binary
+// and for end-to-end tests. It keeps the consumer-group semantics of the
+// other backends: groups start at new messages, reads stay pending until
+// acknowledged and idle pending entries can be reclaimed. Messages are
+// dropped once no group can still deliver them. Nothing survives a restart.
+type Memory struct {
+	mu      sync.Mutex
+	streams map[string]*memStream
+	now     func() time.Time
+	closed  bool
+}
+
+type memStream struct {
+	lastID   int64
+	messages []memMessage
+	groups   map[string]*memGroup
+	// wake is closed when a message is added.
+	wake chan struct{}
+}
+
+type memMessage struct {
+	id     int64
+	values map[string]string
+}
+
+type memGroup struct {
+	lastDelivered int64
+	pending       map[int64]*memPending
+}
+
+type memPending struct {
+	consumer      string
+	deliveredAt   time.Time
+	deliveryCount int64
+}
+
+func NewMemory() *Memory {
+	return &Memory{streams: map[string]*memStream{}, now: time.Now}
+}
+
+// SetClock replaces the clock used for idle times, for deterministic tests.
+func (m *Memory) SetClock(now func() time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = now
+}
+
+// Close wakes blocked readers; every later call returns ErrClosed.
+func (m *Memory) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil
+	}
+	m.closed = true
+	for _, s := range m.streams {
+		close(s.wake)
+	}
+	return nil
+}
+
+func (m *Memory) stream(name string) *memStream {
+	s, ok := m.streams[name]
+	if !ok {
+		s = &memStream{groups: map[string]*memGroup{}, wake: make(chan struct{})}
+		m.streams[name] = s
+	}
+	return s
+}
+
+func (m *Memory) EnsureGroup(ctx context.Context, stream, group string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrClosed
+	}
+	s := m.stream(stream)
+	if _, ok := s.groups[group]; !ok {
+		s.groups[group] = &memGroup{lastDelivered: s.lastID, pending: map[int64]*memPending{}}
+	}
+	return nil
+}
+
+func (m *Memory) AddJob(ctx context.Context, stream string, values map[string]any) (string, error) {
+	ids, err := m.AddJobs(ctx, []StreamMessage{{Stream: stream, Values: values}})
+	if err != nil {
+		return "", err
+	}
+	return ids[0], nil
+}
+
+func (m *Memory) AddJobs(ctx context.Context, msgs []StreamMessage) ([]string, error) {
+	if len(msgs) == 0 {
+		return nil, nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil, ErrClosed
+	}
+
+	ids := make([]string, len(msgs))
+	woken := map[*memStream]bool{}
+	for i, msg := range msgs {
+		s := m.stream(msg.Stream)
+		s.lastID++
+		values := make(map[string]string, len(msg.Values))
+		for k, v := range msg.Values {
+			values[k] = fmt.Sprint(v)
+		}
+		s.messages = append(s.messages, memMessage{id: s.lastID, values: values})
+		s.trim()
+		ids[i] = strconv.FormatInt(s.lastID, 10)
+		woken[s] = true
+	}
+	for s := range woken {
+		close(s.wake)
+		s.wake = make(chan struct{})
+	}
+	return ids, nil
+}
+
+// ReadGroup hands up to count new messages to consumer. As with XREADGROUP,
+// block == 0 waits until a message arrives or ctx ends and block < 0 does not
+// wait at all.
+func (m *Memory) ReadGroup(ctx context.Context, stream, group, consumer string, count int64, block time.Duration) ([]Message, error) {
+	var timeout <-chan time.Time
+	if block > 0 {
+		timer := time.NewTimer(block)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for {
+		m.mu.Lock()
+		if m.closed {
+			m.mu.Unlock()
+			return nil, ErrClosed
+		}
+		s, ok := m.streams[stream]
+		var g *memGroup
+		if ok {
+			g, ok = s.groups[group]
+		}
+		if !ok {
+			m.mu.Unlock()
+			return nil, fmt.Errorf("read group: no group %s on %s", group, stream)
+		}
+		var msgs []Message
+		now := m.now()
+		for _, msg := range s.messages {
+			if msg.id <= g.lastDelivered {
+				continue
+			}
+			if count > 0 && int64(len(msgs)) >= count {
+				break
+			}
+			g.lastDelivered = msg.id
+			g.pending[msg.id] = &memPending{consumer: consumer, deliveredAt: now, deliveryCount: 1}
+			msgs = append(msgs, msg.message())
+		}
+		wake := s.wake
+		m.mu.Unlock()
+
+		if len(msgs) > 0 || block < 0 {
+			return msgs, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timeout:
+			return nil, nil
+		case <-wake:
+		}
+	}
+}
+
+func (m *Memory) Ack(ctx context.Context, stream, group string, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	parsed, err := parseIDs(ids)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrClosed
+	}
+	s, ok := m.streams[stream]
+	if !ok {
+		return nil
+	}
+	g, ok := s.groups[group]
+	if !ok {
+		return nil
+	}
+	for _, id := range parsed {
+		delete(g.pending, id)
+	}
+	s.trim()
+	return nil
+}
+
+func (m *Memory) Outstanding(ctx context.Context, stream, group string, ids ...string) (map[string]bool, error) {
+	parsed, err := parseIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil, ErrClosed
+	}
+	outstanding := make(map[string]bool)
+	s, ok := m.streams[stream]
+	if !ok {
+		return outstanding, nil
+	}
+	g, ok := s.groups[group]
+	if !ok {
+		return outstanding, nil
+	}
+	for i, id := range parsed {
+		if _, pending := g.pending[id]; pending {
+			outstanding[ids[i]] = true
+		} else if _, ok := s.find(id); ok && id > g.lastDelivered {
+			outstanding[ids[i]] = true
+		}
+	}
+	return outstanding, nil
+}
+
+// Reclaim moves up to count entries idle for at least minIdle to consumer and
+// returns them with their delivery count after the claim.
+func (m *Memory) Reclaim(ctx context.Context, stream, group, consumer string, minIdle time.Duration, count int64) ([]Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil, ErrClosed
+	}
+	s, ok := m.streams[stream]
+	if !ok {
+		return nil, nil
+	}
+	g, ok := s.groups[group]
+	if !ok {
+		return nil, fmt.Errorf("reclaim: no group %s on %s", group, stream)
+	}
+
+	ids := make([]int64, 0, len(g.pending))
+	for id := range g.pending {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	now := m.now()
+	var deliveries []Delivery
+	for _, id := range ids {
+		if count > 0 && int64(len(deliveries)) >= count {
+			break
+		}
+		p := g.pending[id]
+		if now.Sub(p.deliveredAt) < minIdle {
+			continue
+		}
+		msg, ok := s.find(id)
+		if !ok {
+			continue
+		}
+		p.consumer = consumer
+		p.deliveredAt = now
+		p.deliveryCount++
+		deliveries = append(deliveries, Delivery{Message: msg.message(), DeliveryCount: p.deliveryCount})
+	}
+	return deliveries, nil
+}
+
+func (s *memStream) find(id int64) (memMessage, bool) {
+	i := sort.Search(len(s.messages), func(i int) bool { return s.messages[i].id >= id })
+	if i < len(s.messages) && s.messages[i].id == id {
+		return s.messages[i], true
+	}
+	return memMessage{}, false
+}
+
+// trim drops the messages every group has delivered and acknowledged. With
+// no groups nothing can read them any more, since new groups start after the
+// last message.
+func (s *memStream) trim() {
+	keepFrom := s.lastID + 1
+	for _, g := range s.groups {
+		if g.lastDelivered+1 < keepFrom {
+			keepFrom = g.lastDelivered + 1
+		}
+		for id := range g.pending {
+			if id < keepFrom {
+				keepFrom = id
+			}
+		}
+	}
+	i := sort.Search(len(s.messages), func(i int) bool { return s.messages[i].id >= keepFrom })
+	if i > 0 {
+		s.messages = append(s.messages[:0:0], s.messages[i:]...)
+	}
+}
+
+func (msg memMessage) message() Message {
+	values := make(map[string]any, len(msg.values))
+	for k, v := range msg.values {
+		values[k] = v
+	}
+	return Message{ID: strconv.FormatInt(msg.id, 10), Values: values}
+}
@@ -0,0 +1,121 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryReclaimWithClock(t *testing.T) {
+	q := NewMemory()
+	defer q.Close()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	q.SetClock(func() time.Time { return now })
+	ctx := context.Background()
+
+	if err := q.EnsureGroup(ctx, "tracking:jobs", "tracking-workers"); err != nil {
+		t.Fatalf("ensure group: %v", err)
+	}
+	id, err := q.AddJob(ctx, "tracking:jobs", map[string]any{"jobId": "1"})
+	if err != nil {
+		t.Fatalf("add job: %v", err)
+	}
+	if _, err := q.ReadGroup(ctx, "tracking:jobs", "tracking-workers", "worker-1", 1, -1); err != nil {
+		t.Fatalf("read group: %v", err)
+	}
+
+	now = now.Add(59 * time.Second)
+	deliveries, err := q.Reclaim(ctx, "tracking:jobs", "tracking-workers", "worker-2", time.Minute, 10)
+	if err != nil {
+		t.Fatalf("reclaim: %v", err)
+	}
+	if len(deliveries) != 0 {
+		t.Fatalf("expected no deliveries before idle threshold, got %d", len(deliveries))
+	}
+
+	now = now.Add(time.Second)
+	deliveries, err = q.Reclaim(ctx, "tracking:jobs", "tracking-workers", "worker-2", time.Minute, 10)
+	if err != nil {
+		t.Fatalf("reclaim: %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Message.ID != id || deliveries[0].DeliveryCount != 2 {
+		t.Fatalf("expected %s reclaimed on second delivery, got %+v", id, deliveries)
+	}
+	deliveries, err = q.Reclaim(ctx, "tracking:jobs", "tracking-workers", "worker-3", time.Minute, 10)
+	if err != nil {
+		t.Fatalf("reclaim: %v", err)
+	}
+	if len(deliveries) != 0 {
+		t.Fatalf("reclaim did not reset idle time: %+v", deliveries)
+	}
+}
+
+func TestMemoryDropsAcknowledgedMessages(t *testing.T) {
+	q := NewMemory()
+	defer q.Close()
+	ctx := context.Background()
+
+	if _, err := q.AddJob(ctx, "tracking:jobs", map[string]any{"n": "0"}); err != nil {
+		t.Fatalf("add job: %v", err)
+	}
+	if n := len(q.streams["tracking:jobs"].messages); n != 0 {
+		t.Fatalf("kept %d messages no group can read", n)
+	}
+
+	for _, group := range []string{"a", "b"} {
+		if err := q.EnsureGroup(ctx, "tracking:jobs", group); err != nil {
+			t.Fatalf("ensure group: %v", err)
+		}
+	}
+	id, err := q.AddJob(ctx, "tracking:jobs", map[string]any{"n": "1"})
+	if err != nil {
+		t.Fatalf("add job: %v", err)
+	}
+	for _, group := range []string{"a", "b"} {
+		if _, err := q.ReadGroup(ctx, "tracking:jobs", group, "worker-1", 1, -1); err != nil {
+			t.Fatalf("read group: %v", err)
+		}
+	}
+
+	if err := q.Ack(ctx, "tracking:jobs", "a", id); err != nil {
+		t.Fatalf("ack: %v", err)
+	}
+	if n := len(q.streams["tracking:jobs"].messages); n != 1 {
+		t.Fatalf("expected message kept for group b, got %d messages", n)
+	}
+	if err := q.Ack(ctx, "tracking:jobs", "b", id); err != nil {
+		t.Fatalf("ack: %v", err)
+	}
+	if n := len(q.streams["tracking:jobs"].messages); n != 0 {
+		t.Fatalf("expected acknowledged message dropped, got %d messages", n)
+	}
+}
+
+func TestMemoryCloseWakesReaders(t *testing.T) {
+	q := NewMemory()
+	ctx := context.Background()
+	if err := q.EnsureGroup(ctx, "tracking:jobs", "tracking-workers"); err != nil {
+		t.Fatalf("ensure group: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := q.ReadGroup(ctx, "tracking:jobs", "tracking-workers", "worker-1", 1, 0)
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	q.Close()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrClosed) {
+			t.Fatalf("expected ErrClosed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("blocked read not woken by Close")
+	}
+	if _, err := q.AddJob(ctx, "tracking:jobs", map[string]any{"n": "1"}); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected ErrClosed after close, got %v", err)
+	}
+}
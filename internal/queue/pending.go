@@ -0,0 +1,270 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type PendingEntry struct {
+	ID            string
+	Consumer      string
+	Idle          time.Duration
+	DeliveryCount int64
+}
+
+type Delivery struct {
+	Message       Message
+	DeliveryCount int64
+}
+
+func (c *Client) Pending(ctx context.Context, stream, group string, minIdle time.Duration, count int64) ([]PendingEntry, error) {
+	res, err := c.redis.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: stream,
+		Group:  group,
+		Idle:   minIdle,
+		Start:  "-",
+		End:    "+",
+		Count:  count,
+	}).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("xpending: %w", err)
+	}
+
+	entries := make([]PendingEntry, 0, len(res))
+	for _, p := range res {
+		entries = append(entries, PendingEntry{
+			ID:            p.ID,
+			Consumer:      p.Consumer,
+			Idle:          p.Idle,
+			DeliveryCount: p.RetryCount,
+		})
+	}
+	return entries, nil
+}
+
+// Outstanding checks ids past the group's last-delivered-id against the
+// stream and the rest against the group's pending entries list.
+func (c *Client) Outstanding(ctx context.Context, stream, group string, ids ...string) (map[string]bool, error) {
+	outstanding := make(map[string]bool)
+	if len(ids) == 0 {
+		return outstanding, nil
+	}
+	groups, err := c.redis.XInfoGroups(ctx, stream).Result()
+	if err != nil {
+		if strings.Contains(err.Error(), "no such key") {
+			return outstanding, nil
+		}
+		return nil, fmt.Errorf("xinfo groups: %w", err)
+	}
+	lastDelivered := ""
+	for _, g := range groups {
+		if g.Name == group {
+			lastDelivered = g.LastDeliveredID
+		}
+	}
+	if lastDelivered == "" {
+		return outstanding, nil
+	}
+
+	pipe := c.redis.Pipeline()
+	unread := make(map[string]*redis.XMessageSliceCmd)
+	pending := make(map[string]*redis.XPendingExtCmd)
+	for _, id := range ids {
+		after, err := streamIDAfter(id, lastDelivered)
+		if err != nil {
+			return nil, err
+		}
+		if after {
+			unread[id] = pipe.XRange(ctx, stream, id, id)
+		} else {
+			pending[id] = pipe.XPendingExt(ctx, &redis.XPendingExtArgs{
+				Stream: stream,
+				Group:  group,
+				Start:  id,
+				End:    id,
+				Count:  1,
+			})
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("outstanding: %w", err)
+	}
+	for id, cmd := range unread {
+		if len(cmd.Val()) > 0 {
+			outstanding[id] = true
+		}
+	}
+	for id, cmd := range pending {
+		if len(cmd.Val()) > 0 {
+			outstanding[id] = true
+		}
+	}
+	return outstanding, nil
+}
+
+// streamIDAfter reports whether the stream entry id a sorts after b.
+func streamIDAfter(a, b string) (bool, error) {
+	ams, aseq, err := splitStreamID(a)
+	if err != nil {
+		return false, err
+	}
+	bms, bseq, err := splitStreamID(b)
+	if err != nil {
+		return false, err
+	}
+	return ams > bms || (ams == bms && aseq > bseq), nil
+}
+
+func splitStreamID(id string) (uint64, uint64, error) {
+	ms, seq, _ := strings.Cut(id, "-")
+	m, err := strconv.ParseUint(ms, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid message id %q", id)
+	}
+	var n uint64
+	if seq != "" {
+		if n, err = strconv.ParseUint(seq, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid message id %q", id)
+		}
+	}
+	return m, n, nil
+}
+
+// Reclaim moves up to count entries idle for at least minIdle to consumer and
+// returns them with their delivery count after the claim.
+func (c *Client) Reclaim(ctx context.Context, stream, group, consumer string, minIdle time.Duration, count int64) ([]Delivery, error) {
+	var claimed []redis.XMessage
+	start := "0-0"
+	for int64(len(claimed)) < count {
+		msgs, next, err := c.redis.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   stream,
+			Group:    group,
+			Consumer: consumer,
+			MinIdle:  minIdle,
+			Start:    start,
+			Count:    count - int64(len(claimed)),
+		}).Result()
+		if err != nil {
+			if err == redis.Nil {
+				break
+			}
+			return nil, fmt.Errorf("xautoclaim: %w", err)
+		}
+		claimed = append(claimed, msgs...)
+		if next == "0-0" || next == "" {
+			break
+		}
+		start = next
+	}
+	if len(claimed) == 0 {
+		return nil, nil
+	}
+
+	counts, err := c.deliveryCounts(ctx, stream, group, consumer, claimed)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]Delivery, 0, len(claimed))
+	for i, msg := range claimed {
+		deliveries = append(deliveries, Delivery{Message: msg, DeliveryCount: counts[i]})
+	}
+	return deliveries, nil
+}
+
+func (c *Client) deliveryCounts(ctx context.Context, stream, group, consumer string, msgs []redis.XMessage) ([]int64, error) {
+	pipe := c.redis.Pipeline()
+	cmds := make([]*redis.XPendingExtCmd, 0, len(msgs))
+	for _, msg := range msgs {
+		cmds = append(cmds, pipe.XPendingExt(ctx, &redis.XPendingExtArgs{
+			Stream:   stream,
+			Group:    group,
+			Start:    msg.ID,
+			End:      msg.ID,
+			Count:    1,
+			Consumer: consumer,
+		}))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("xpending: %w", err)
+	}
+
+	counts := make([]int64, len(msgs))
+	for i, cmd := range cmds {
+		res, err := cmd.Result()
+		if err != nil && err != redis.Nil {
+			return nil, fmt.Errorf("xpending: %w", err)
+		}
+		if len(res) > 0 {
+			counts[i] = res[0].RetryCount
+		}
+	}
+	return counts, nil
+}
+
+type ReclaimConfig struct {
+	Stream   string
+	Group    string
+	Consumer string
+	MinIdle  time.Duration
+	Interval time.Duration
+	Count    int64
+	OnError  func(error)
+}
+
+type Reclaimer struct {
+	client Queue
+	cfg    ReclaimConfig
+}
+
+func NewReclaimer(client Queue, cfg ReclaimConfig) *Reclaimer {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 30 * time.Second
+	}
+	if cfg.Count <= 0 {
+		cfg.Count = 10
+	}
+	return &Reclaimer{client: client, cfg: cfg}
+}
+
+func (r *Reclaimer) RunOnce(ctx context.Context) ([]Delivery, error) {
+	return r.client.Reclaim(ctx, r.cfg.Stream, r.cfg.Group, r.cfg.Consumer, r.cfg.MinIdle, r.cfg.Count)
+}
+
+// Run reclaims stalled entries every Interval and hands them to handle until
+// ctx is cancelled. Reclaim errors are reported to OnError and retried on the
+// next tick.
+func (r *Reclaimer) Run(ctx context.Context, handle func(context.Context, []Delivery)) error {
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		deliveries, err := r.RunOnce(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if r.cfg.OnError != nil {
+				r.cfg.OnError(err)
+			}
+			continue
+		}
+		if len(deliveries) > 0 {
+			handle(ctx, deliveries)
+		}
+	}
+}
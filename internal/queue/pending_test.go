@@ -0,0 +1,76 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestReclaimStalledMessages(t *testing.T) {
+	mini, err := miniredis.Run()
+	if err != nil {
+		t.Skipf("miniredis unavailable: %v", err)
+	}
+	defer mini.Close()
+
+	client := New(mini.Addr())
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	mini.SetTime(now)
+
+	if err := client.EnsureGroup(ctx, "tracking:jobs", "tracking-workers"); err != nil {
+		t.Fatalf("ensure group: %v", err)
+	}
+	if _, err := client.AddJob(ctx, "tracking:jobs", map[string]any{"jobId": "1"}); err != nil {
+		t.Fatalf("add job: %v", err)
+	}
+	if _, err := client.ReadGroup(ctx, "tracking:jobs", "tracking-workers", "worker-1", 1, 10*time.Millisecond); err != nil {
+		t.Fatalf("read group: %v", err)
+	}
+
+	deliveries, err := client.Reclaim(ctx, "tracking:jobs", "tracking-workers", "worker-2", time.Minute, 10)
+	if err != nil {
+		t.Fatalf("reclaim: %v", err)
+	}
+	if len(deliveries) != 0 {
+		t.Fatalf("expected no deliveries before idle threshold, got %d", len(deliveries))
+	}
+
+	mini.SetTime(now.Add(2 * time.Minute))
+
+	pending, err := client.Pending(ctx, "tracking:jobs", "tracking-workers", time.Minute, 10)
+	if err != nil {
+		t.Fatalf("pending: %v", err)
+	}
+	if len(pending) != 1 || pending[0].Consumer != "worker-1" {
+		t.Fatalf("expected 1 pending entry for worker-1, got %+v", pending)
+	}
+
+	deliveries, err = client.Reclaim(ctx, "tracking:jobs", "tracking-workers", "worker-2", time.Minute, 10)
+	if err != nil {
+		t.Fatalf("reclaim: %v", err)
+	}
+	if len(deliveries) != 1 {
+		t.Fatalf("expected 1 delivery, got %d", len(deliveries))
+	}
+	if deliveries[0].DeliveryCount != 2 {
+		t.Fatalf("expected delivery count 2, got %d", deliveries[0].DeliveryCount)
+	}
+	if deliveries[0].Message.Values["jobId"] != "1" {
+		t.Fatalf("unexpected values: %v", deliveries[0].Message.Values)
+	}
+
+	pending, err = client.Pending(ctx, "tracking:jobs", "tracking-workers", 0, 10)
+	if err != nil {
+		t.Fatalf("pending: %v", err)
+	}
+	if len(pending) != 1 || pending[0].Consumer != "worker-2" {
+		t.Fatalf("expected entry owned by worker-2, got %+v", pending)
+	}
+}
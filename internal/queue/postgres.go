@@ -0,0 +1,410 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// notifyChannel is signalled with the stream name by a trigger on
+// queue_messages (migration 016).
+const notifyChannel = "logisync_queue"
+
+// Postgres is a Queue on the queue_* tables. Consumers claim messages with
+// FOR UPDATE SKIP LOCKED, and blocked reads are woken through LISTEN/NOTIFY
+// on one connection shared by the whole Postgres. Message ids are the
+// decimal queue_messages ids.
+type Postgres struct {
+	pool *pgxpool.Pool
+
+	mu      sync.Mutex
+	waiters map[string]chan struct{}
+	stop    context.CancelFunc
+	done    chan struct{}
+}
+
+func NewPostgres(pool *pgxpool.Pool) *Postgres {
+	return &Postgres{pool: pool, waiters: map[string]chan struct{}{}}
+}
+
+// Close stops the listener; the pool is left open.
+func (p *Postgres) Close() error {
+	p.mu.Lock()
+	stop, done := p.stop, p.done
+	p.stop, p.done = nil, nil
+	p.mu.Unlock()
+	if stop != nil {
+		stop()
+		<-done
+	}
+	return nil
+}
+
+func (p *Postgres) EnsureGroup(ctx context.Context, stream, group string) error {
+	_, err := p.pool.Exec(ctx, `
+		INSERT INTO queue_groups (stream, name)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, stream, group)
+	if err != nil {
+		return fmt.Errorf("create group: %w", err)
+	}
+	return nil
+}
+
+const addMessageSQL = `
+	WITH m AS (
+		INSERT INTO queue_messages (stream, payload)
+		VALUES ($1, $2)
+		RETURNING id
+	), d AS (
+		INSERT INTO queue_deliveries (stream, group_name, message_id)
+		SELECT $1, g.name, m.id FROM queue_groups g, m
+		WHERE g.stream = $1
+	)
+	SELECT id FROM m
+`
+
+func (p *Postgres) AddJob(ctx context.Context, stream string, values map[string]any) (string, error) {
+	payload, err := encodeValues(values)
+	if err != nil {
+		return "", err
+	}
+	var id int64
+	if err := p.pool.QueryRow(ctx, addMessageSQL, stream, payload).Scan(&id); err != nil {
+		return "", fmt.Errorf("add message: %w", err)
+	}
+	return strconv.FormatInt(id, 10), nil
+}
+
+// AddJobs adds msgs in one transaction, so either all of them or none are
+// added.
+func (p *Postgres) AddJobs(ctx context.Context, msgs []StreamMessage) ([]string, error) {
+	if len(msgs) == 0 {
+		return nil, nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, msg := range msgs {
+		payload, err := encodeValues(msg.Values)
+		if err != nil {
+			return nil, err
+		}
+		batch.Queue(addMessageSQL, msg.Stream, payload)
+	}
+
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin add messages: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	results := tx.SendBatch(ctx, batch)
+	ids := make([]string, 0, len(msgs))
+	for range msgs {
+		var id int64
+		if err := results.QueryRow().Scan(&id); err != nil {
+			results.Close()
+			return nil, fmt.Errorf("add message: %w", err)
+		}
+		ids = append(ids, strconv.FormatInt(id, 10))
+	}
+	if err := results.Close(); err != nil {
+		return nil, fmt.Errorf("add messages: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit add messages: %w", err)
+	}
+	return ids, nil
+}
+
+// ReadGroup claims up to count new messages for consumer. As with XREADGROUP,
+// block == 0 waits until a message arrives or ctx ends, block < 0 does not
+// wait at all and a group that was never created is an error.
+func (p *Postgres) ReadGroup(ctx context.Context, stream, group, consumer string, count int64, block time.Duration) ([]Message, error) {
+	var exists bool
+	err := p.pool.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM queue_groups WHERE stream = $1 AND name = $2)
+	`, stream, group).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("read group: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("read group: no group %s on %s", group, stream)
+	}
+
+	var wake <-chan struct{}
+	if block >= 0 {
+		wake = p.wait(stream)
+	}
+	var timeout <-chan time.Time
+	if block > 0 {
+		timer := time.NewTimer(block)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for {
+		deliveries, err := p.claim(ctx, stream, group, consumer, count, `consumer IS NULL`)
+		if err != nil {
+			return nil, fmt.Errorf("read group: %w", err)
+		}
+		if len(deliveries) > 0 {
+			msgs := make([]Message, len(deliveries))
+			for i, d := range deliveries {
+				msgs[i] = d.Message
+			}
+			return msgs, nil
+		}
+		if wake == nil {
+			return nil, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timeout:
+			return nil, nil
+		case <-wake:
+			wake = p.wait(stream)
+		}
+	}
+}
+
+func (p *Postgres) Ack(ctx context.Context, stream, group string, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	messageIDs, err := parseIDs(ids)
+	if err != nil {
+		return err
+	}
+	// A message is deleted with its last group's delivery. The outer NOT EXISTS
+	// still sees the rows deleted by acked, hence the group filter.
+	_, err = p.pool.Exec(ctx, `
+		WITH acked AS (
+			DELETE FROM queue_deliveries
+			WHERE stream = $1 AND group_name = $2 AND message_id = ANY($3) AND consumer IS NOT NULL
+			RETURNING message_id
+		)
+		DELETE FROM queue_messages m
+		USING acked
+		WHERE m.id = acked.message_id
+			AND NOT EXISTS (
+				SELECT 1 FROM queue_deliveries d WHERE d.message_id = m.id AND d.group_name <> $2
+			)
+	`, stream, group, messageIDs)
+	if err != nil {
+		return fmt.Errorf("ack: %w", err)
+	}
+	return nil
+}
+
+// Outstanding relies on a delivery row living from the add until the ack, read
+// or not.
+func (p *Postgres) Outstanding(ctx context.Context, stream, group string, ids ...string) (map[string]bool, error) {
+	messageIDs, err := parseIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	outstanding := make(map[string]bool)
+	if len(ids) == 0 {
+		return outstanding, nil
+	}
+	rows, err := p.pool.Query(ctx, `
+		SELECT message_id FROM queue_deliveries
+		WHERE stream = $1 AND group_name = $2 AND message_id = ANY($3)
+	`, stream, group, messageIDs)
+	if err != nil {
+		return nil, fmt.Errorf("outstanding: %w", err)
+	}
+	found, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return nil, fmt.Errorf("outstanding: %w", err)
+	}
+	for _, id := range found {
+		outstanding[strconv.FormatInt(id, 10)] = true
+	}
+	return outstanding, nil
+}
+
+// Reclaim moves up to count entries idle for at least minIdle to consumer and
+// returns them with their delivery count after the claim.
+func (p *Postgres) Reclaim(ctx context.Context, stream, group, consumer string, minIdle time.Duration, count int64) ([]Delivery, error) {
+	deliveries, err := p.claim(ctx, stream, group, consumer, count,
+		`consumer IS NOT NULL AND delivered_at <= now() - $5 * interval '1 second'`, minIdle.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("reclaim: %w", err)
+	}
+	return deliveries, nil
+}
+
+// claim hands the deliveries of stream and group matching where to consumer,
+// oldest message first, skipping rows other consumers are claiming. where may
+// use args as $5 onwards.
+func (p *Postgres) claim(ctx context.Context, stream, group, consumer string, count int64, where string, args ...any) ([]Delivery, error) {
+	var limit *int64
+	if count > 0 {
+		limit = &count
+	}
+	rows, err := p.pool.Query(ctx, `
+		UPDATE queue_deliveries d
+		SET consumer = $3, delivered_at = now(), delivery_count = d.delivery_count + 1
+		FROM (
+			SELECT message_id FROM queue_deliveries
+			WHERE stream = $1 AND group_name = $2 AND `+where+`
+			ORDER BY message_id
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		) next, queue_messages m
+		WHERE d.stream = $1 AND d.group_name = $2 AND d.message_id = next.message_id AND m.id = d.message_id
+		RETURNING d.message_id, m.payload, d.delivery_count
+	`, append([]any{stream, group, consumer, limit}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type claimed struct {
+		id       int64
+		delivery Delivery
+	}
+	var all []claimed
+	for rows.Next() {
+		var c claimed
+		var payload []byte
+		if err := rows.Scan(&c.id, &payload, &c.delivery.DeliveryCount); err != nil {
+			return nil, err
+		}
+		var values map[string]string
+		if err := json.Unmarshal(payload, &values); err != nil {
+			return nil, fmt.Errorf("decode message %d: %w", c.id, err)
+		}
+		c.delivery.Message = Message{ID: strconv.FormatInt(c.id, 10), Values: make(map[string]any, len(values))}
+		for k, v := range values {
+			c.delivery.Message.Values[k] = v
+		}
+		all = append(all, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(all) == 0 {
+		return nil, nil
+	}
+	// RETURNING does not keep the subquery's order.
+	sort.Slice(all, func(i, j int) bool { return all[i].id < all[j].id })
+	deliveries := make([]Delivery, len(all))
+	for i, c := range all {
+		deliveries[i] = c.delivery
+	}
+	return deliveries, nil
+}
+
+// wait returns a channel closed on the next notification for stream,
+// starting the listener if needed.
+func (p *Postgres) wait(stream string) <-chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stop == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		p.stop, p.done = cancel, make(chan struct{})
+		go p.listen(ctx, p.done)
+	}
+	ch, ok := p.waiters[stream]
+	if !ok {
+		ch = make(chan struct{})
+		p.waiters[stream] = ch
+	}
+	return ch
+}
+
+func (p *Postgres) wake(stream string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if ch, ok := p.waiters[stream]; ok {
+		close(ch)
+		delete(p.waiters, stream)
+	}
+}
+
+func (p *Postgres) wakeAll() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for stream, ch := range p.waiters {
+		close(ch)
+		delete(p.waiters, stream)
+	}
+}
+
+// listen forwards notifications to waiters until ctx ends. When the
+// connection drops, every waiter is woken to poll, and the listener
+// reconnects after a second.
+func (p *Postgres) listen(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	for {
+		_ = p.listenOnce(ctx)
+		p.wakeAll()
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (p *Postgres) listenOnce(ctx context.Context) error {
+	pooled, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+notifyChannel); err != nil {
+		return err
+	}
+	// Messages added before LISTEN took effect would otherwise go unnoticed
+	// until the readers' block expires.
+	p.wakeAll()
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		p.wake(n.Payload)
+	}
+}
+
+// encodeValues stores values as strings, matching what Redis hands back.
+func encodeValues(values map[string]any) ([]byte, error) {
+	fields := make(map[string]string, len(values))
+	for k, v := range values {
+		fields[k] = fmt.Sprint(v)
+	}
+	payload, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("encode message: %w", err)
+	}
+	return payload, nil
+}
+
+func parseIDs(ids []string) ([]int64, error) {
+	parsed := make([]int64, len(ids))
+	for i, id := range ids {
+		n, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid message id %q", id)
+		}
+		parsed[i] = n
+	}
+	return parsed, nil
+}
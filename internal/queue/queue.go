@@ -0,0 +1,72 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+// Message is a stream entry as handed to a consumer. Every backend returns
+// field values as strings, as Redis does.
+type Message = redis.XMessage
+
+const (
+	BackendRedis    = "redis"
+	BackendPostgres = "postgres"
+	BackendMemory   = "memory"
+)
+
+// Queue is the job queue with Redis Streams consumer-group semantics: a group
+// sees every message added after it was created, a message read by one of
+// its consumers stays pending until acknowledged, and pending messages idle
+// for long enough can be reclaimed by another consumer. Client implements it
+// on Redis, Postgres on Postgres and Memory in process.
+type Queue interface {
+	EnsureGroup(ctx context.Context, stream, group string) error
+	AddJob(ctx context.Context, stream string, values map[string]any) (string, error)
+	AddJobs(ctx context.Context, msgs []StreamMessage) ([]string, error)
+	ReadGroup(ctx context.Context, stream, group, consumer string, count int64, block time.Duration) ([]Message, error)
+	Ack(ctx context.Context, stream, group string, ids ...string) error
+	Reclaim(ctx context.Context, stream, group, consumer string, minIdle time.Duration, count int64) ([]Delivery, error)
+	// Outstanding reports which of ids group has still to read or has read
+	// without acknowledging. Ids the group can no longer reach, including
+	// every id on a missing stream or group, are left out.
+	Outstanding(ctx context.Context, stream, group string, ids ...string) (map[string]bool, error)
+	Close() error
+}
+
+var (
+	_ Queue = (*Client)(nil)
+	_ Queue = (*Postgres)(nil)
+	_ Queue = (*Memory)(nil)
+)
+
+// Open returns the queue for backend, as named by QUEUE_BACKEND. pool is only
+// used by the Postgres backend and stays owned by the caller. A memory queue
+// only reaches consumers in the same process, so the API and worker must
+// share the returned value.
+func Open(backend, redisAddr string, pool *pgxpool.Pool) (Queue, error) {
+	switch backend {
+	case "", BackendRedis:
+		return New(redisAddr), nil
+	case BackendPostgres:
+		return NewPostgres(pool), nil
+	case BackendMemory:
+		return NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown queue backend %q", backend)
+	}
+}
+
+// owns reports whether q is c itself, or nil, so that Redis-side bookkeeping
+// such as the schedule and the dlq can move messages in the same transaction.
+func (c *Client) owns(q Queue) bool {
+	if q == nil {
+		return true
+	}
+	other, ok := q.(*Client)
+	return ok && other == c
+}
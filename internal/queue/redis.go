@@ -38,7 +38,7 @@ func (c *Client) AddJob(ctx context.Context, stream string, values map[string]an
 	return id, nil
 }
 
-func (c *Client) ReadGroup(ctx context.Context, stream, group, consumer string, count int64, block time.Duration) ([]redis.XMessage, error) {
+func (c *Client) ReadGroup(ctx context.Context, stream, group, consumer string, count int64, block time.Duration) ([]Message, error) {
 	res, err := c.redis.XReadGroup(ctx, &redis.XReadGroupArgs{
 		Group:    group,
 		Consumer: consumer,
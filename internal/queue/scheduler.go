@@ -0,0 +1,226 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// promoteDueScript pops due members from the schedule ZSET and XADDs them to
+// the stream in one step, so concurrent schedulers never deliver twice.
+// Members are JSON arrays of alternating field names and values.
+var promoteDueScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+local ids = {}
+for _, member in ipairs(due) do
+  redis.call('ZREM', KEYS[1], member)
+  local fields = cjson.decode(member)
+  table.insert(ids, redis.call('XADD', KEYS[2], '*', unpack(fields)))
+end
+return ids
+`)
+
+// Schedule stores values in the schedule ZSET to be added to the stream at
+// the given time. Scheduling the same values again moves the due time.
+func (c *Client) Schedule(ctx context.Context, key string, values map[string]any, at time.Time) error {
+	member, err := encodeScheduled(values)
+	if err != nil {
+		return err
+	}
+	if err := c.redis.ZAdd(ctx, key, redis.Z{Score: float64(at.UnixMilli()), Member: member}).Err(); err != nil {
+		return fmt.Errorf("zadd: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) Unschedule(ctx context.Context, key string, values map[string]any) (bool, error) {
+	member, err := encodeScheduled(values)
+	if err != nil {
+		return false, err
+	}
+	n, err := c.redis.ZRem(ctx, key, member).Result()
+	if err != nil {
+		return false, fmt.Errorf("zrem: %w", err)
+	}
+	return n > 0, nil
+}
+
+// PromoteDue moves up to limit entries due at or before now from the schedule
+// ZSET into stream and returns the new stream message IDs.
+func (c *Client) PromoteDue(ctx context.Context, key, stream string, now time.Time, limit int64) ([]string, error) {
+	res, err := promoteDueScript.Run(ctx, c.redis, []string{key, stream}, now.UnixMilli(), limit).StringSlice()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("promote due: %w", err)
+	}
+	return res, nil
+}
+
+// PromoteDueTo is PromoteDue for a stream on q, the job queue. On c itself it
+// is PromoteDue. Otherwise each due entry is taken off the ZSET with ZREM, so
+// concurrent schedulers still deliver it once, and added back when AddJob
+// fails. An entry taken by a scheduler that dies before AddJob is dropped;
+// its job stays PENDING and the outbox reconciler enqueues it.
+func (c *Client) PromoteDueTo(ctx context.Context, q Queue, key, stream string, now time.Time, limit int64) ([]string, error) {
+	if c.owns(q) {
+		return c.PromoteDue(ctx, key, stream, now, limit)
+	}
+	due, err := c.redis.ZRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now.UnixMilli(), 10),
+		Count: limit,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("promote due: %w", err)
+	}
+
+	var ids []string
+	for _, z := range due {
+		member, _ := z.Member.(string)
+		values, err := decodeScheduled(member)
+		if err != nil {
+			return ids, err
+		}
+		removed, err := c.redis.ZRem(ctx, key, member).Result()
+		if err != nil {
+			return ids, fmt.Errorf("zrem: %w", err)
+		}
+		if removed == 0 {
+			continue
+		}
+		id, err := q.AddJob(ctx, stream, values)
+		if err != nil {
+			if zerr := c.redis.ZAdd(ctx, key, z).Err(); zerr != nil {
+				return ids, errors.Join(fmt.Errorf("promote due: %w", err), fmt.Errorf("zadd: %w", zerr))
+			}
+			return ids, fmt.Errorf("promote due: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func encodeScheduled(values map[string]any) (string, error) {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fields := make([]string, 0, len(values)*2)
+	for _, k := range keys {
+		var v string
+		switch val := values[k].(type) {
+		case string:
+			v = val
+		case int:
+			v = strconv.Itoa(val)
+		case int64:
+			v = strconv.FormatInt(val, 10)
+		default:
+			v = fmt.Sprint(val)
+		}
+		fields = append(fields, k, v)
+	}
+	if len(fields) == 0 {
+		return "", fmt.Errorf("schedule: empty values")
+	}
+	member, err := json.Marshal(fields)
+	if err != nil {
+		return "", fmt.Errorf("encode schedule member: %w", err)
+	}
+	return string(member), nil
+}
+
+func decodeScheduled(member string) (map[string]any, error) {
+	var fields []string
+	if err := json.Unmarshal([]byte(member), &fields); err != nil || len(fields)%2 != 0 {
+		return nil, fmt.Errorf("decode schedule member %q", member)
+	}
+	values := make(map[string]any, len(fields)/2)
+	for i := 0; i < len(fields); i += 2 {
+		values[fields[i]] = fields[i+1]
+	}
+	return values, nil
+}
+
+type SchedulerConfig struct {
+	Key      string
+	Stream   string
+	Interval time.Duration
+	Batch    int64
+	OnError  func(error)
+}
+
+// Scheduler keeps the schedule in Redis and promotes due entries into the
+// job queue, whichever backend that is.
+type Scheduler struct {
+	client *Client
+	queue  Queue
+	cfg    SchedulerConfig
+}
+
+// NewScheduler stores the schedule on client and delivers to q; nil means
+// client.
+func NewScheduler(client *Client, q Queue, cfg SchedulerConfig) *Scheduler {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.Batch <= 0 {
+		cfg.Batch = 100
+	}
+	return &Scheduler{client: client, queue: q, cfg: cfg}
+}
+
+func (s *Scheduler) Schedule(ctx context.Context, values map[string]any, at time.Time) error {
+	return s.client.Schedule(ctx, s.cfg.Key, values, at)
+}
+
+func (s *Scheduler) Unschedule(ctx context.Context, values map[string]any) (bool, error) {
+	return s.client.Unschedule(ctx, s.cfg.Key, values)
+}
+
+// RunOnce promotes due entries until fewer than a full batch remain.
+func (s *Scheduler) RunOnce(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		ids, err := s.client.PromoteDueTo(ctx, s.queue, s.cfg.Key, s.cfg.Stream, time.Now(), s.cfg.Batch)
+		total += len(ids)
+		if err != nil {
+			return total, err
+		}
+		if int64(len(ids)) < s.cfg.Batch {
+			return total, nil
+		}
+	}
+}
+
+func (s *Scheduler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		if _, err := s.RunOnce(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if s.cfg.OnError != nil {
+				s.cfg.OnError(err)
+			}
+		}
+	}
+}
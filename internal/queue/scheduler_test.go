@@ -0,0 +1,126 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestPromoteDue(t *testing.T) {
+	mini, err := miniredis.Run()
+	if err != nil {
+		t.Skipf("miniredis unavailable: %v", err)
+	}
+	defer mini.Close()
+
+	client := New(mini.Addr())
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := client.EnsureGroup(ctx, "tracking:jobs", "tracking-workers"); err != nil {
+		t.Fatalf("ensure group: %v", err)
+	}
+
+	now := time.Now()
+	due := map[string]any{"jobId": "due", "provider": "dummy", "trackingCode": "AA123"}
+	later := map[string]any{"jobId": "later", "provider": "dummy", "trackingCode": "BB456"}
+	if err := client.Schedule(ctx, "tracking:jobs:scheduled", due, now.Add(-time.Second)); err != nil {
+		t.Fatalf("schedule due: %v", err)
+	}
+	if err := client.Schedule(ctx, "tracking:jobs:scheduled", later, now.Add(time.Hour)); err != nil {
+		t.Fatalf("schedule later: %v", err)
+	}
+
+	ids, err := client.PromoteDue(ctx, "tracking:jobs:scheduled", "tracking:jobs", now, 10)
+	if err != nil {
+		t.Fatalf("promote: %v", err)
+	}
+	if len(ids) != 1 {
+		t.Fatalf("expected 1 promoted entry, got %d", len(ids))
+	}
+
+	ids, err = client.PromoteDue(ctx, "tracking:jobs:scheduled", "tracking:jobs", now, 10)
+	if err != nil {
+		t.Fatalf("promote again: %v", err)
+	}
+	if len(ids) != 0 {
+		t.Fatalf("expected no double delivery, got %d", len(ids))
+	}
+
+	messages, err := client.ReadGroup(ctx, "tracking:jobs", "tracking-workers", "worker-1", 10, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("read group: %v", err)
+	}
+	if len(messages) != 1 || messages[0].Values["jobId"] != "due" || messages[0].Values["trackingCode"] != "AA123" {
+		t.Fatalf("unexpected messages: %+v", messages)
+	}
+
+	removed, err := client.Unschedule(ctx, "tracking:jobs:scheduled", later)
+	if err != nil {
+		t.Fatalf("unschedule: %v", err)
+	}
+	if !removed {
+		t.Fatalf("expected later entry to be removed")
+	}
+}
+
+func TestPromoteDueToOtherBackend(t *testing.T) {
+	mini, err := miniredis.Run()
+	if err != nil {
+		t.Skipf("miniredis unavailable: %v", err)
+	}
+	defer mini.Close()
+
+	client := New(mini.Addr())
+	defer client.Close()
+	jobs := NewMemory()
+	defer jobs.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := jobs.EnsureGroup(ctx, "tracking:jobs", "tracking-workers"); err != nil {
+		t.Fatalf("ensure group: %v", err)
+	}
+	now := time.Now()
+	due := map[string]any{"jobId": "due", "provider": "dummy", "trackingCode": "AA123"}
+	if err := client.Schedule(ctx, "tracking:jobs:scheduled", due, now.Add(-time.Second)); err != nil {
+		t.Fatalf("schedule: %v", err)
+	}
+
+	scheduler := NewScheduler(client, jobs, SchedulerConfig{Key: "tracking:jobs:scheduled", Stream: "tracking:jobs"})
+	promoted, err := scheduler.RunOnce(ctx)
+	if err != nil {
+		t.Fatalf("run once: %v", err)
+	}
+	if promoted != 1 {
+		t.Fatalf("expected 1 promoted entry, got %d", promoted)
+	}
+	if promoted, _ := scheduler.RunOnce(ctx); promoted != 0 {
+		t.Fatalf("expected no double delivery, got %d", promoted)
+	}
+
+	messages, err := jobs.ReadGroup(ctx, "tracking:jobs", "tracking-workers", "worker-1", 10, -1)
+	if err != nil {
+		t.Fatalf("read group: %v", err)
+	}
+	if len(messages) != 1 || messages[0].Values["jobId"] != "due" || messages[0].Values["trackingCode"] != "AA123" {
+		t.Fatalf("unexpected messages: %+v", messages)
+	}
+
+	// A queue that refuses the message leaves the entry scheduled.
+	if err := client.Schedule(ctx, "tracking:jobs:scheduled", due, now.Add(-time.Second)); err != nil {
+		t.Fatalf("schedule: %v", err)
+	}
+	jobs.Close()
+	if _, err := scheduler.RunOnce(ctx); err == nil {
+		t.Fatalf("expected promote to a closed queue to fail")
+	}
+	if members, _ := mini.ZMembers("tracking:jobs:scheduled"); len(members) != 1 {
+		t.Fatalf("expected the entry to be rescheduled, got %v", members)
+	}
+}
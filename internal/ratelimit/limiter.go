@@ -0,0 +1,177 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"logisync/internal/providers"
+)
+
+// takeScript refills the bucket for the elapsed time and takes one token.
+// While a cool-down factor is set, both rate and burst are scaled by it.
+// Returns {allowed, wait_ms}.
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local factor = tonumber(redis.call('GET', KEYS[2]) or '1')
+rate = rate * factor
+burst = math.max(1, math.floor(burst * factor))
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+if now > ts then
+  tokens = math.min(burst, tokens + (now - ts) * rate)
+end
+tokens = math.min(tokens, burst)
+
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  wait = math.ceil((1 - tokens) / rate)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate) * 2 + 1000)
+return {allowed, wait}
+`)
+
+// penalizeScript multiplies the current cool-down factor, drains the bucket
+// and restarts the cool-down window.
+var penalizeScript = redis.NewScript(`
+local factor = tonumber(redis.call('GET', KEYS[2]) or '1') * tonumber(ARGV[1])
+factor = math.max(factor, tonumber(ARGV[2]))
+redis.call('SET', KEYS[2], tostring(factor), 'PX', ARGV[3])
+redis.call('HSET', KEYS[1], 'tokens', '0', 'ts', ARGV[4])
+return tostring(factor)
+`)
+
+type Limit struct {
+	PerMinute int
+	Burst     int
+}
+
+type Config struct {
+	// Limits are the RATE_LIMIT_PER_MINUTE/RATE_LIMIT_BURST overrides. A
+	// provider without a per-minute override falls back to the rate its
+	// Capabilities declare, then to Default.
+	Limits       map[string]Limit
+	Capabilities func(provider string) (providers.Capabilities, error)
+	Default      Limit
+	// Cooldown is how long a RATE_LIMITED response keeps the bucket shrunk
+	// by CooldownFactor. Repeated 429s compound down to MinFactor.
+	Cooldown       time.Duration
+	CooldownFactor float64
+	MinFactor      float64
+	Prefix         string
+}
+
+type Limiter struct {
+	redis *redis.Client
+	cfg   Config
+	now   func() time.Time
+}
+
+func New(addr string, cfg Config) *Limiter {
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = time.Minute
+	}
+	if cfg.CooldownFactor <= 0 || cfg.CooldownFactor >= 1 {
+		cfg.CooldownFactor = 0.5
+	}
+	if cfg.MinFactor <= 0 {
+		cfg.MinFactor = 0.05
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = "ratelimit"
+	}
+	return &Limiter{
+		redis: redis.NewClient(&redis.Options{Addr: addr}),
+		cfg:   cfg,
+		now:   time.Now,
+	}
+}
+
+func (l *Limiter) Close() error {
+	return l.redis.Close()
+}
+
+func LimitFromCapabilities(caps providers.Capabilities) Limit {
+	return Limit{PerMinute: caps.RateLimitPerMinute}
+}
+
+func (l *Limiter) limitFor(provider string) Limit {
+	limit, ok := l.cfg.Limits[provider]
+	if limit.PerMinute <= 0 && l.cfg.Capabilities != nil {
+		if caps, err := l.cfg.Capabilities(provider); err == nil && caps.RateLimitPerMinute > 0 {
+			limit.PerMinute = LimitFromCapabilities(caps).PerMinute
+			ok = true
+		}
+	}
+	if !ok {
+		limit = l.cfg.Default
+	}
+	if limit.Burst <= 0 {
+		limit.Burst = 1
+	}
+	return limit
+}
+
+// Reserve takes a token for provider. A zero duration means the call may go
+// ahead; otherwise no token was taken and the job should be deferred by the
+// returned duration.
+func (l *Limiter) Reserve(ctx context.Context, provider string) (time.Duration, error) {
+	limit := l.limitFor(provider)
+	if limit.PerMinute <= 0 {
+		return 0, nil
+	}
+
+	ratePerMs := float64(limit.PerMinute) / float64(time.Minute.Milliseconds())
+	res, err := takeScript.Run(ctx, l.redis, l.keys(provider), ratePerMs, limit.Burst, l.now().UnixMilli()).Int64Slice()
+	if err != nil {
+		return 0, fmt.Errorf("rate limit %s: %w", provider, err)
+	}
+	if len(res) != 2 {
+		return 0, fmt.Errorf("rate limit %s: unexpected reply %v", provider, res)
+	}
+	if res[0] == 1 {
+		return 0, nil
+	}
+	return time.Duration(res[1]) * time.Millisecond, nil
+}
+
+// Penalize shrinks provider's bucket for the cool-down period.
+func (l *Limiter) Penalize(ctx context.Context, provider string) (float64, error) {
+	res, err := penalizeScript.Run(ctx, l.redis, l.keys(provider),
+		l.cfg.CooldownFactor, l.cfg.MinFactor, l.cfg.Cooldown.Milliseconds(), l.now().UnixMilli()).Float64()
+	if err != nil {
+		return 0, fmt.Errorf("penalize %s: %w", provider, err)
+	}
+	return res, nil
+}
+
+// Observe penalizes provider when err is a RATE_LIMITED provider error.
+func (l *Limiter) Observe(ctx context.Context, provider string, err error) error {
+	var providerErr *providers.Error
+	if err == nil || !errors.As(err, &providerErr) || providerErr.Code != "RATE_LIMITED" {
+		return nil
+	}
+	_, perr := l.Penalize(ctx, provider)
+	return perr
+}
+
+func (l *Limiter) keys(provider string) []string {
+	return []string{
+		l.cfg.Prefix + ":" + provider + ":bucket",
+		l.cfg.Prefix + ":" + provider + ":cooldown",
+	}
+}
@@ -0,0 +1,132 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"logisync/internal/providers"
+)
+
+func newTestLimiter(t *testing.T, cfg Config) (*Limiter, *time.Time) {
+	t.Helper()
+	mini, err := miniredis.Run()
+	if err != nil {
+		t.Skipf("miniredis unavailable: %v", err)
+	}
+	t.Cleanup(mini.Close)
+
+	limiter := New(mini.Addr(), cfg)
+	t.Cleanup(func() { limiter.Close() })
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+	return limiter, &now
+}
+
+func TestReserveTokenBucket(t *testing.T) {
+	limiter, now := newTestLimiter(t, Config{
+		Limits: map[string]Limit{"mock_portal_scrape": {PerMinute: 60, Burst: 2}},
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		wait, err := limiter.Reserve(ctx, "mock_portal_scrape")
+		if err != nil {
+			t.Fatalf("reserve: %v", err)
+		}
+		if wait != 0 {
+			t.Fatalf("expected burst token %d to be allowed, got wait %s", i, wait)
+		}
+	}
+
+	wait, err := limiter.Reserve(ctx, "mock_portal_scrape")
+	if err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	if wait != time.Second {
+		t.Fatalf("expected 1s wait at 60/min, got %s", wait)
+	}
+
+	*now = now.Add(time.Second)
+	wait, err = limiter.Reserve(ctx, "mock_portal_scrape")
+	if err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	if wait != 0 {
+		t.Fatalf("expected refilled token, got wait %s", wait)
+	}
+}
+
+func TestReserveUnlimitedProvider(t *testing.T) {
+	limiter, _ := newTestLimiter(t, Config{})
+	for i := 0; i < 10; i++ {
+		wait, err := limiter.Reserve(context.Background(), "dummy")
+		if err != nil || wait != 0 {
+			t.Fatalf("expected unlimited provider to pass, got %s %v", wait, err)
+		}
+	}
+}
+
+func TestObserveRateLimitedShrinksBucket(t *testing.T) {
+	limiter, now := newTestLimiter(t, Config{
+		Limits:   map[string]Limit{"mock_portal_scrape": {PerMinute: 60, Burst: 4}},
+		Cooldown: time.Minute,
+	})
+	ctx := context.Background()
+
+	if err := limiter.Observe(ctx, "mock_portal_scrape", &providers.Error{Code: "TIMEOUT"}); err != nil {
+		t.Fatalf("observe: %v", err)
+	}
+	if err := limiter.Observe(ctx, "mock_portal_scrape", &providers.Error{Code: "RATE_LIMITED"}); err != nil {
+		t.Fatalf("observe: %v", err)
+	}
+
+	wait, err := limiter.Reserve(ctx, "mock_portal_scrape")
+	if err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	if wait != 2*time.Second {
+		t.Fatalf("expected drained bucket at half rate to wait 2s, got %s", wait)
+	}
+
+	*now = now.Add(10 * time.Second)
+	allowed := 0
+	for i := 0; i < 4; i++ {
+		if wait, _ := limiter.Reserve(ctx, "mock_portal_scrape"); wait == 0 {
+			allowed++
+		}
+	}
+	if allowed != 2 {
+		t.Fatalf("expected burst halved to 2 during cool-down, got %d", allowed)
+	}
+}
+
+func TestLimitsFallBackToCapabilities(t *testing.T) {
+	registry := providers.NewRegistry()
+	_ = registry.Register("declared", func() (providers.Provider, error) { return nil, nil }, providers.Capabilities{RateLimitPerMinute: 60})
+	_ = registry.Register("overridden", func() (providers.Provider, error) { return nil, nil }, providers.Capabilities{RateLimitPerMinute: 60})
+	_ = registry.Register("undeclared", func() (providers.Provider, error) { return nil, nil }, providers.Capabilities{})
+
+	limiter, _ := newTestLimiter(t, Config{
+		Limits: map[string]Limit{
+			"declared":   {Burst: 3},
+			"overridden": {PerMinute: 120, Burst: 2},
+		},
+		Capabilities: registry.Capabilities,
+		Default:      Limit{PerMinute: 10},
+	})
+
+	for provider, want := range map[string]Limit{
+		"declared":   {PerMinute: 60, Burst: 3},
+		"overridden": {PerMinute: 120, Burst: 2},
+		"undeclared": {PerMinute: 10, Burst: 1},
+		"unknown":    {PerMinute: 10, Burst: 1},
+	} {
+		if got := limiter.limitFor(provider); got != want {
+			t.Fatalf("%s: expected %+v, got %+v", provider, want, got)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"logisync/internal/db/repo"
+	"logisync/internal/queue"
+	"logisync/internal/workerutil"
+)
+
+type DispatcherConfig struct {
+	Stream   string
+	Interval time.Duration
+	Batch    int
+	OnError  func(error)
+}
+
+// Dispatcher re-enqueues RETRY_SCHEDULED jobs once their next_attempt_at is due.
+type Dispatcher struct {
+	jobs  *repo.JobRepo
+	queue queue.Queue
+	cfg   DispatcherConfig
+}
+
+func NewDispatcher(jobs *repo.JobRepo, q queue.Queue, cfg DispatcherConfig) *Dispatcher {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 5 * time.Second
+	}
+	if cfg.Batch <= 0 {
+		cfg.Batch = 100
+	}
+	return &Dispatcher{jobs: jobs, queue: q, cfg: cfg}
+}
+
+func (d *Dispatcher) RunOnce(ctx context.Context) (int, error) {
+	due, err := d.jobs.ClaimDueRetries(ctx, time.Now().UTC(), d.cfg.Batch)
+	if err != nil {
+		return 0, err
+	}
+
+	enqueued := 0
+	var errs []error
+	for _, job := range due {
+		if _, err := d.queue.AddJob(ctx, d.cfg.Stream, workerutil.NewMessage(job.ID, job.Provider, job.TrackingCode)); err != nil {
+			errs = append(errs, fmt.Errorf("enqueue job %s: %w", job.ID, err))
+			// Put the job back, keeping the error that caused the retry, so the
+			// next tick picks it up again.
+			if rerr := d.jobs.RescheduleRetry(ctx, job.ID, time.Now().UTC().Add(d.cfg.Interval),
+				repo.Change{Actor: repo.ActorRetryDispatcher, Reason: "QUEUE_ERROR"}); rerr != nil {
+				errs = append(errs, fmt.Errorf("reschedule job %s: %w", job.ID, rerr))
+			}
+			continue
+		}
+		enqueued++
+	}
+	return enqueued, errors.Join(errs...)
+}
+
+func (d *Dispatcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(d.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		if _, err := d.RunOnce(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if d.cfg.OnError != nil {
+				d.cfg.OnError(err)
+			}
+		}
+	}
+}
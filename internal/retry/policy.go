@@ -0,0 +1,92 @@
+package retry
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+
+	"logisync/internal/providers"
+)
+
+var retryableCodes = map[string]bool{
+	"TIMEOUT":        true,
+	"RATE_LIMITED":   true,
+	"PROVIDER_ERROR": true,
+}
+
+func Retryable(code string) bool {
+	return retryableCodes[code]
+}
+
+type Policy struct {
+	BaseDelay           time.Duration
+	MaxDelay            time.Duration
+	MaxAttempts         int
+	ProviderMaxAttempts map[string]int
+	// Jitter is the fraction of the computed delay that is randomised, 0..1.
+	Jitter float64
+
+	rand func() float64
+}
+
+func DefaultPolicy() Policy {
+	return Policy{
+		BaseDelay:   5 * time.Second,
+		MaxDelay:    10 * time.Minute,
+		MaxAttempts: 5,
+		Jitter:      0.2,
+	}
+}
+
+type Decision struct {
+	Retry         bool
+	NextAttemptAt time.Time
+	Code          string
+	Message       string
+}
+
+func (p Policy) MaxAttemptsFor(provider string) int {
+	if max, ok := p.ProviderMaxAttempts[provider]; ok {
+		return max
+	}
+	return p.MaxAttempts
+}
+
+// Backoff returns the delay before the attempt following the given one
+// (attempts is 1-based): BaseDelay * 2^(attempts-1), capped at MaxDelay, with
+// up to Jitter of it randomised.
+func (p Policy) Backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := float64(p.BaseDelay) * math.Pow(2, float64(attempts-1))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		rnd := p.rand
+		if rnd == nil {
+			rnd = rand.Float64
+		}
+		delay -= delay * p.Jitter * rnd()
+	}
+	return time.Duration(delay)
+}
+
+// Decide classifies err from a provider call made on the given attempt.
+// Non-provider errors are treated as PROVIDER_ERROR.
+func (p Policy) Decide(provider string, attempts int, err error, now time.Time) Decision {
+	decision := Decision{Code: "PROVIDER_ERROR", Message: err.Error()}
+	var providerErr *providers.Error
+	if errors.As(err, &providerErr) && providerErr.Code != "" {
+		decision.Code = providerErr.Code
+	}
+
+	if !Retryable(decision.Code) || attempts >= p.MaxAttemptsFor(provider) {
+		return decision
+	}
+	decision.Retry = true
+	decision.NextAttemptAt = now.Add(p.Backoff(attempts))
+	return decision
+}
@@ -0,0 +1,79 @@
+package retry
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"logisync/internal/providers"
+)
+
+func TestRetryable(t *testing.T) {
+	for _, code := range []string{"TIMEOUT", "RATE_LIMITED", "PROVIDER_ERROR"} {
+		if !Retryable(code) {
+			t.Fatalf("expected %s to be retryable", code)
+		}
+	}
+	for _, code := range []string{"INVALID_INPUT", "AUTH_ERROR", "PARSE_ERROR"} {
+		if Retryable(code) {
+			t.Fatalf("expected %s to be terminal", code)
+		}
+	}
+}
+
+func TestBackoffExponentialAndCapped(t *testing.T) {
+	policy := Policy{BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+	if got := policy.Backoff(1); got != time.Second {
+		t.Fatalf("expected 1s, got %s", got)
+	}
+	if got := policy.Backoff(3); got != 4*time.Second {
+		t.Fatalf("expected 4s, got %s", got)
+	}
+	if got := policy.Backoff(10); got != 10*time.Second {
+		t.Fatalf("expected cap of 10s, got %s", got)
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	policy := Policy{BaseDelay: 10 * time.Second, Jitter: 0.5, rand: func() float64 { return 1 }}
+	if got := policy.Backoff(1); got != 5*time.Second {
+		t.Fatalf("expected 5s with full jitter, got %s", got)
+	}
+}
+
+func TestDecide(t *testing.T) {
+	policy := Policy{
+		BaseDelay:           time.Second,
+		MaxAttempts:         3,
+		ProviderMaxAttempts: map[string]int{"dummy": 1},
+	}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	decision := policy.Decide("mock_portal_scrape", 1, &providers.Error{Code: "TIMEOUT", Message: "slow"}, now)
+	if !decision.Retry || decision.Code != "TIMEOUT" {
+		t.Fatalf("expected retry on TIMEOUT, got %+v", decision)
+	}
+	if !decision.NextAttemptAt.Equal(now.Add(time.Second)) {
+		t.Fatalf("unexpected next attempt: %s", decision.NextAttemptAt)
+	}
+
+	decision = policy.Decide("mock_portal_scrape", 1, &providers.Error{Code: "INVALID_INPUT", Message: "bad"}, now)
+	if decision.Retry {
+		t.Fatalf("expected no retry on INVALID_INPUT")
+	}
+
+	decision = policy.Decide("mock_portal_scrape", 3, &providers.Error{Code: "RATE_LIMITED", Message: "slow down"}, now)
+	if decision.Retry {
+		t.Fatalf("expected no retry after max attempts")
+	}
+
+	decision = policy.Decide("dummy", 1, &providers.Error{Code: "TIMEOUT", Message: "slow"}, now)
+	if decision.Retry {
+		t.Fatalf("expected provider cap to stop retries")
+	}
+
+	decision = policy.Decide("mock_portal_scrape", 1, errors.New("boom"), now)
+	if !decision.Retry || decision.Code != "PROVIDER_ERROR" {
+		t.Fatalf("expected generic error to retry as PROVIDER_ERROR, got %+v", decision)
+	}
+}
@@ -0,0 +1,94 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"logisync/internal/db/repo"
+)
+
+type ReaperConfig struct {
+	Interval time.Duration
+	Batch    int
+	// Policy decides, by attempts so far, whether a job is re-run or failed.
+	Policy  Policy
+	OnError func(error)
+}
+
+// Reaper takes back RUNNING jobs whose worker stopped renewing its lease. A
+// job under its attempt limit goes back to PENDING; one at the limit fails
+// with LEASE_EXPIRED. Nothing is enqueued: the dead worker's message is still
+// pending and the Reclaimer hands it to another worker. Should that message
+// be gone, the outbox reconciler picks the job up.
+type Reaper struct {
+	jobs     *repo.JobRepo
+	attempts *repo.AttemptRepo
+	cfg      ReaperConfig
+}
+
+func NewReaper(jobs *repo.JobRepo, attempts *repo.AttemptRepo, cfg ReaperConfig) *Reaper {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 30 * time.Second
+	}
+	if cfg.Batch <= 0 {
+		cfg.Batch = 100
+	}
+	if cfg.Policy.MaxAttempts <= 0 {
+		cfg.Policy = DefaultPolicy()
+	}
+	return &Reaper{jobs: jobs, attempts: attempts, cfg: cfg}
+}
+
+// RunOnce returns how many jobs it took back.
+func (r *Reaper) RunOnce(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	expired, err := r.jobs.ExpiredLeases(ctx, now, r.cfg.Batch)
+	if err != nil {
+		return 0, err
+	}
+
+	reaped := 0
+	for _, job := range expired {
+		fail := job.Attempts >= r.cfg.Policy.MaxAttemptsFor(job.Provider)
+		_, ok, err := r.jobs.ReleaseExpiredLease(ctx, job.ID, now, fail, repo.Change{Actor: repo.ActorLeaseReaper})
+		if err != nil {
+			return reaped, fmt.Errorf("release job %s: %w", job.ID, err)
+		}
+		if !ok {
+			continue
+		}
+		reaped++
+
+		message := "lease expired"
+		if job.LockedBy != nil {
+			message = fmt.Sprintf("lease held by %s expired", *job.LockedBy)
+		}
+		if _, err := r.attempts.FinishOpen(ctx, job.ID, repo.AttemptFailed, "LEASE_EXPIRED", message); err != nil {
+			return reaped, err
+		}
+	}
+	return reaped, nil
+}
+
+func (r *Reaper) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		if _, err := r.RunOnce(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if r.cfg.OnError != nil {
+				r.cfg.OnError(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"logisync/internal/db/repo"
+	"logisync/internal/queue"
+	"logisync/internal/workerutil"
+)
+
+// Requeuer re-runs finished jobs on operator request, keeping their ids.
+// A job whose AddJob fails stays PENDING and is picked up by the outbox
+// reconciler.
+type Requeuer struct {
+	jobs   *repo.JobRepo
+	queue  queue.Queue
+	stream string
+}
+
+func NewRequeuer(jobs *repo.JobRepo, q queue.Queue, stream string) *Requeuer {
+	return &Requeuer{jobs: jobs, queue: q, stream: stream}
+}
+
+// Requeue re-runs one job. It returns repo.ErrNotRetryable for jobs that
+// have not finished and sql.ErrNoRows for unknown ids.
+func (r *Requeuer) Requeue(ctx context.Context, id uuid.UUID, change repo.Change) (repo.Job, error) {
+	job, err := r.jobs.Requeue(ctx, id, change)
+	if err != nil {
+		return repo.Job{}, err
+	}
+	if _, err := r.queue.AddJob(ctx, r.stream, workerutil.NewMessage(job.ID, job.Provider, job.TrackingCode)); err != nil {
+		return job, fmt.Errorf("enqueue job %s: %w", job.ID, err)
+	}
+	return job, nil
+}
+
+// RequeueMatching re-runs up to f.Limit finished jobs matching f and returns
+// the ones that were enqueued. Enqueue errors are joined; those jobs are
+// left PENDING for the reconciler.
+func (r *Requeuer) RequeueMatching(ctx context.Context, f repo.JobFilter, change repo.Change) ([]repo.Job, error) {
+	jobs, err := r.jobs.RequeueMatching(ctx, f, change)
+	if err != nil {
+		return nil, err
+	}
+	enqueued := make([]repo.Job, 0, len(jobs))
+	var errs []error
+	for _, job := range jobs {
+		if _, err := r.queue.AddJob(ctx, r.stream, workerutil.NewMessage(job.ID, job.Provider, job.TrackingCode)); err != nil {
+			errs = append(errs, fmt.Errorf("enqueue job %s: %w", job.ID, err))
+			continue
+		}
+		enqueued = append(enqueued, job)
+	}
+	return enqueued, errors.Join(errs...)
+}
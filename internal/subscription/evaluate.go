@@ -0,0 +1,141 @@
+package subscription
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"logisync/internal/db/repo"
+)
+
+const (
+	StatusActive    = "ACTIVE"
+	StatusCompleted = "COMPLETED"
+	StatusExpired   = "EXPIRED"
+	StatusExhausted = "EXHAUSTED"
+	StatusCancelled = "CANCELLED"
+)
+
+var DefaultTerminalStatuses = []string{"DELIVERED", "RETURNED"}
+
+type Options struct {
+	Interval         time.Duration
+	MaxInterval      time.Duration
+	MaxAge           time.Duration
+	MaxPolls         int
+	TerminalStatuses []string
+}
+
+// New builds an ACTIVE subscription that polls immediately.
+func New(provider, trackingCode string, opts Options, now time.Time) repo.Subscription {
+	interval := int(opts.Interval.Seconds())
+	if interval <= 0 {
+		interval = int((2 * time.Hour).Seconds())
+	}
+	maxInterval := int(opts.MaxInterval.Seconds())
+	if maxInterval < interval {
+		maxInterval = interval
+	}
+	terminal := opts.TerminalStatuses
+	if len(terminal) == 0 {
+		terminal = DefaultTerminalStatuses
+	}
+
+	sub := repo.Subscription{
+		ID:                     uuid.New(),
+		Provider:               provider,
+		TrackingCode:           trackingCode,
+		Status:                 StatusActive,
+		IntervalSeconds:        interval,
+		CurrentIntervalSeconds: interval,
+		MaxIntervalSeconds:     maxInterval,
+		MaxPolls:               opts.MaxPolls,
+		TerminalStatuses:       terminal,
+		NextPollAt:             now,
+	}
+	if opts.MaxAge > 0 {
+		expiresAt := now.Add(opts.MaxAge)
+		sub.ExpiresAt = &expiresAt
+	}
+	return sub
+}
+
+// Observation describes the job spawned by the previous poll, if any.
+type Observation struct {
+	JobStatus    string
+	HasResult    bool
+	ResultStatus string
+}
+
+type Step struct {
+	Stop            string
+	StopReason      string
+	Spawn           bool
+	LastStatus      *string
+	IntervalSeconds int
+	NextPollAt      time.Time
+}
+
+// Evaluate decides what a due subscription should do next. An unchanged
+// status doubles the interval up to MaxIntervalSeconds; a change resets it.
+func Evaluate(sub repo.Subscription, obs Observation, now time.Time) Step {
+	step := Step{LastStatus: sub.LastStatus, IntervalSeconds: sub.CurrentIntervalSeconds}
+	if step.IntervalSeconds <= 0 {
+		step.IntervalSeconds = sub.IntervalSeconds
+	}
+
+	if obs.HasResult {
+		status := obs.ResultStatus
+		if isTerminal(sub.TerminalStatuses, status) {
+			step.Stop = StatusCompleted
+			step.StopReason = "terminal status " + status
+			step.LastStatus = &status
+			return step
+		}
+		if sub.LastStatus != nil && strings.EqualFold(*sub.LastStatus, status) {
+			step.IntervalSeconds *= 2
+			if step.IntervalSeconds > sub.MaxIntervalSeconds {
+				step.IntervalSeconds = sub.MaxIntervalSeconds
+			}
+		} else {
+			step.IntervalSeconds = sub.IntervalSeconds
+		}
+		step.LastStatus = &status
+	} else if inFlight(obs.JobStatus) {
+		step.NextPollAt = now.Add(time.Duration(step.IntervalSeconds) * time.Second)
+		return step
+	}
+
+	if sub.ExpiresAt != nil && !now.Before(*sub.ExpiresAt) {
+		step.Stop = StatusExpired
+		step.StopReason = "max age reached"
+		return step
+	}
+	if sub.MaxPolls > 0 && sub.Polls >= sub.MaxPolls {
+		step.Stop = StatusExhausted
+		step.StopReason = "max polls reached"
+		return step
+	}
+
+	step.Spawn = true
+	step.NextPollAt = now.Add(time.Duration(step.IntervalSeconds) * time.Second)
+	return step
+}
+
+func isTerminal(terminal []string, status string) bool {
+	for _, t := range terminal {
+		if strings.EqualFold(t, status) {
+			return true
+		}
+	}
+	return false
+}
+
+func inFlight(jobStatus string) bool {
+	switch jobStatus {
+	case "PENDING", "RUNNING", "RETRY_SCHEDULED":
+		return true
+	}
+	return false
+}
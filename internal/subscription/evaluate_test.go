@@ -0,0 +1,81 @@
+package subscription
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewDefaults(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sub := New("dummy", "AA123", Options{Interval: time.Hour, MaxAge: 24 * time.Hour}, now)
+	if sub.Status != StatusActive {
+		t.Fatalf("expected ACTIVE, got %s", sub.Status)
+	}
+	if sub.IntervalSeconds != 3600 || sub.MaxIntervalSeconds != 3600 {
+		t.Fatalf("unexpected intervals: %+v", sub)
+	}
+	if sub.ExpiresAt == nil || !sub.ExpiresAt.Equal(now.Add(24*time.Hour)) {
+		t.Fatalf("unexpected expiry: %v", sub.ExpiresAt)
+	}
+	if len(sub.TerminalStatuses) != len(DefaultTerminalStatuses) {
+		t.Fatalf("expected default terminal statuses")
+	}
+}
+
+func TestEvaluateStopsOnTerminalStatus(t *testing.T) {
+	now := time.Now()
+	sub := New("dummy", "AA123", Options{Interval: time.Hour}, now)
+	step := Evaluate(sub, Observation{JobStatus: "DONE", HasResult: true, ResultStatus: "delivered"}, now)
+	if step.Stop != StatusCompleted || step.Spawn {
+		t.Fatalf("expected completion, got %+v", step)
+	}
+}
+
+func TestEvaluateAdaptsInterval(t *testing.T) {
+	now := time.Now()
+	sub := New("dummy", "AA123", Options{Interval: time.Hour, MaxInterval: 3 * time.Hour}, now)
+	status := "IN_TRANSIT"
+	sub.LastStatus = &status
+
+	step := Evaluate(sub, Observation{JobStatus: "DONE", HasResult: true, ResultStatus: "IN_TRANSIT"}, now)
+	if !step.Spawn || step.IntervalSeconds != 7200 {
+		t.Fatalf("expected doubled interval, got %+v", step)
+	}
+
+	sub.CurrentIntervalSeconds = 7200
+	step = Evaluate(sub, Observation{JobStatus: "DONE", HasResult: true, ResultStatus: "IN_TRANSIT"}, now)
+	if step.IntervalSeconds != 10800 {
+		t.Fatalf("expected capped interval, got %d", step.IntervalSeconds)
+	}
+
+	step = Evaluate(sub, Observation{JobStatus: "DONE", HasResult: true, ResultStatus: "OUT_FOR_DELIVERY"}, now)
+	if step.IntervalSeconds != 3600 {
+		t.Fatalf("expected interval reset on change, got %d", step.IntervalSeconds)
+	}
+	if !step.NextPollAt.Equal(now.Add(time.Hour)) {
+		t.Fatalf("unexpected next poll: %s", step.NextPollAt)
+	}
+}
+
+func TestEvaluateWaitsForInFlightJob(t *testing.T) {
+	now := time.Now()
+	sub := New("dummy", "AA123", Options{Interval: time.Hour}, now)
+	step := Evaluate(sub, Observation{JobStatus: "RUNNING"}, now)
+	if step.Spawn || step.Stop != "" {
+		t.Fatalf("expected reschedule only, got %+v", step)
+	}
+}
+
+func TestEvaluateStopConditions(t *testing.T) {
+	now := time.Now()
+	sub := New("dummy", "AA123", Options{Interval: time.Hour, MaxAge: time.Hour}, now.Add(-2*time.Hour))
+	if step := Evaluate(sub, Observation{}, now); step.Stop != StatusExpired {
+		t.Fatalf("expected EXPIRED, got %+v", step)
+	}
+
+	sub = New("dummy", "AA123", Options{Interval: time.Hour, MaxPolls: 2}, now)
+	sub.Polls = 2
+	if step := Evaluate(sub, Observation{JobStatus: "FAILED"}, now); step.Stop != StatusExhausted {
+		t.Fatalf("expected EXHAUSTED, got %+v", step)
+	}
+}
@@ -0,0 +1,151 @@
+package subscription
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"logisync/internal/db/repo"
+	"logisync/internal/workerutil"
+)
+
+type PollerConfig struct {
+	Stream   string
+	Interval time.Duration
+	Lease    time.Duration
+	Batch    int
+	OnError  func(error)
+}
+
+// Poller spawns tracking jobs for due subscriptions through the same
+// JobRepo.CreateWithOutbox path the API uses.
+type Poller struct {
+	subs    *repo.SubscriptionRepo
+	jobs    *repo.JobRepo
+	results *repo.ResultRepo
+	cfg     PollerConfig
+}
+
+func NewPoller(subs *repo.SubscriptionRepo, jobs *repo.JobRepo, results *repo.ResultRepo, cfg PollerConfig) *Poller {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 10 * time.Second
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = time.Minute
+	}
+	if cfg.Batch <= 0 {
+		cfg.Batch = 100
+	}
+	return &Poller{subs: subs, jobs: jobs, results: results, cfg: cfg}
+}
+
+func (p *Poller) RunOnce(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	due, err := p.subs.ClaimDue(ctx, now, p.cfg.Lease, p.cfg.Batch)
+	if err != nil {
+		return 0, err
+	}
+
+	// A failing subscription does not hold up the rest of the batch; its lease
+	// runs out and it is polled again.
+	spawned := 0
+	var errs []error
+	for _, sub := range due {
+		ok, err := p.poll(ctx, sub, now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("poll subscription %s: %w", sub.ID, err))
+			continue
+		}
+		if ok {
+			spawned++
+		}
+	}
+	return spawned, errors.Join(errs...)
+}
+
+func (p *Poller) Run(ctx context.Context) error {
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		if _, err := p.RunOnce(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if p.cfg.OnError != nil {
+				p.cfg.OnError(err)
+			}
+		}
+	}
+}
+
+func (p *Poller) poll(ctx context.Context, sub repo.Subscription, now time.Time) (bool, error) {
+	obs, err := p.observe(ctx, sub)
+	if err != nil {
+		return false, err
+	}
+
+	step := Evaluate(sub, obs, now)
+	if step.Stop != "" {
+		return false, p.subs.Stop(ctx, sub.ID, step.Stop, step.StopReason, step.LastStatus)
+	}
+	if !step.Spawn {
+		return false, p.subs.Reschedule(ctx, sub.ID, step.LastStatus, step.IntervalSeconds, step.NextPollAt)
+	}
+
+	job := repo.Job{ID: uuid.New(), Provider: sub.Provider, TrackingCode: sub.TrackingCode, Status: "PENDING"}
+	if _, _, err := p.jobs.CreateWithOutbox(ctx, job, nil, p.cfg.Stream, workerutil.NewMessage(job.ID, job.Provider, job.TrackingCode)); err != nil {
+		return false, err
+	}
+	if err := p.subs.RecordPoll(ctx, sub.ID, job.ID, step.LastStatus, step.IntervalSeconds, step.NextPollAt); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (p *Poller) observe(ctx context.Context, sub repo.Subscription) (Observation, error) {
+	if sub.LastJobID == nil {
+		return Observation{}, nil
+	}
+
+	job, err := p.jobs.Get(ctx, *sub.LastJobID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Observation{}, nil
+		}
+		return Observation{}, fmt.Errorf("get last job: %w", err)
+	}
+	obs := Observation{JobStatus: job.Status}
+
+	result, err := p.results.GetLatestByJobID(ctx, job.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return obs, nil
+		}
+		return Observation{}, err
+	}
+	obs.HasResult = true
+	obs.ResultStatus = result.Status
+	if obs.ResultStatus == "" {
+		// Results stored before status normalization only carry it in the payload.
+		var payload struct {
+			Status string `json:"status"`
+		}
+		if err := json.Unmarshal(result.NormalizedPayload, &payload); err != nil {
+			return Observation{}, fmt.Errorf("parse result payload: %w", err)
+		}
+		obs.ResultStatus = payload.Status
+	}
+	return obs, nil
+}
@@ -0,0 +1,135 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"logisync/internal/db/repo"
+	"logisync/internal/retry"
+)
+
+const maxResponseBody = 1024
+
+type DelivererConfig struct {
+	Interval time.Duration
+	Lease    time.Duration
+	Batch    int
+	Timeout  time.Duration
+	// Backoff spaces out attempts; its MaxAttempts bounds them.
+	Backoff retry.Policy
+	Client  *http.Client
+	OnError func(error)
+}
+
+// Deliverer POSTs pending webhook deliveries and records each attempt.
+type Deliverer struct {
+	webhooks *repo.WebhookRepo
+	cfg      DelivererConfig
+}
+
+func NewDeliverer(webhooks *repo.WebhookRepo, cfg DelivererConfig) *Deliverer {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 2 * time.Second
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = cfg.Timeout + time.Minute
+	}
+	if cfg.Batch <= 0 {
+		cfg.Batch = 50
+	}
+	if cfg.Backoff.BaseDelay <= 0 {
+		cfg.Backoff = retry.Policy{BaseDelay: 10 * time.Second, MaxDelay: time.Hour, MaxAttempts: 8, Jitter: 0.2}
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: cfg.Timeout}
+	}
+	return &Deliverer{webhooks: webhooks, cfg: cfg}
+}
+
+func (d *Deliverer) RunOnce(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	due, err := d.webhooks.ClaimDueDeliveries(ctx, now, d.cfg.Lease, d.cfg.Batch)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, delivery := range due {
+		attempt := Send(ctx, d.cfg.Client, delivery, time.Now())
+		if !attempt.Delivered && delivery.Attempts+1 < d.cfg.Backoff.MaxAttempts {
+			attempt.NextAttemptAt = time.Now().UTC().Add(d.cfg.Backoff.Backoff(delivery.Attempts + 1))
+		}
+		if err := d.webhooks.RecordAttempt(ctx, delivery.ID, attempt); err != nil {
+			return delivered, fmt.Errorf("record delivery %s: %w", delivery.ID, err)
+		}
+		if attempt.Delivered {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+func (d *Deliverer) Run(ctx context.Context) error {
+	ticker := time.NewTicker(d.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		if _, err := d.RunOnce(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if d.cfg.OnError != nil {
+				d.cfg.OnError(err)
+			}
+		}
+	}
+}
+
+// Send makes one signed POST of the delivery's payload. Any 2xx response
+// counts as delivered; the caller decides when to try again.
+func Send(ctx context.Context, client *http.Client, delivery repo.WebhookDelivery, now time.Time) repo.DeliveryAttempt {
+	var attempt repo.DeliveryAttempt
+	fail := func(err error) repo.DeliveryAttempt {
+		msg := err.Error()
+		attempt.ErrorMessage = &msg
+		return attempt
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return fail(fmt.Errorf("build request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, delivery.ID.String())
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, now, delivery.Payload))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fail(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	code, text := resp.StatusCode, string(body)
+	attempt.ResponseCode = &code
+	attempt.ResponseBody = &text
+	if code >= 200 && code < 300 {
+		attempt.Delivered = true
+		return attempt
+	}
+	return fail(fmt.Errorf("endpoint responded %d", code))
+}
@@ -0,0 +1,62 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"logisync/internal/db/repo"
+)
+
+func TestSend(t *testing.T) {
+	now := time.Now()
+	var gotErr error
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotErr = Verify("s3cret", r.Header.Get(SignatureHeader), body, now, time.Minute)
+		if r.Header.Get(EventHeader) != EventJobSucceeded {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	delivery := repo.WebhookDelivery{
+		ID:        uuid.New(),
+		EventType: EventJobSucceeded,
+		Payload:   []byte(`{"type":"job.succeeded"}`),
+		URL:       srv.URL,
+		Secret:    "s3cret",
+	}
+	attempt := Send(context.Background(), srv.Client(), delivery, now)
+	if gotErr != nil {
+		t.Fatalf("receiver could not verify signature: %v", gotErr)
+	}
+	if !attempt.Delivered || attempt.ResponseCode == nil || *attempt.ResponseCode != http.StatusAccepted || *attempt.ResponseBody != "ok" {
+		t.Fatalf("unexpected attempt: %+v", attempt)
+	}
+
+	delivery.EventType = EventJobFailed
+	attempt = Send(context.Background(), srv.Client(), delivery, now)
+	if attempt.Delivered || *attempt.ResponseCode != http.StatusBadRequest || attempt.ErrorMessage == nil {
+		t.Fatalf("expected rejected delivery, got %+v", attempt)
+	}
+}
+
+func TestSendConnectionError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	attempt := Send(context.Background(), http.DefaultClient, repo.WebhookDelivery{URL: url, Payload: []byte(`{}`)}, time.Now())
+	if attempt.Delivered || attempt.ResponseCode != nil || attempt.ErrorMessage == nil {
+		t.Fatalf("expected connection failure, got %+v", attempt)
+	}
+}
@@ -0,0 +1,107 @@
+package webhook
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"logisync/internal/db/repo"
+	"logisync/internal/providers"
+)
+
+const (
+	EventJobSucceeded          = "job.succeeded"
+	EventJobFailed             = "job.failed"
+	EventShipmentStatusChanged = "shipment.status_changed"
+	EventShipmentEventAdded    = "shipment.event_added"
+)
+
+var knownEvents = map[string]bool{
+	EventJobSucceeded:          true,
+	EventJobFailed:             true,
+	EventShipmentStatusChanged: true,
+	EventShipmentEventAdded:    true,
+}
+
+// ValidateEvents checks an endpoint's event filter. An empty filter
+// subscribes to every event.
+func ValidateEvents(events []string) error {
+	for _, evt := range events {
+		if !knownEvents[evt] {
+			return fmt.Errorf("unknown webhook event %q", evt)
+		}
+	}
+	return nil
+}
+
+type Event struct {
+	ID        uuid.UUID `json:"event_id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+type JobData struct {
+	JobID        uuid.UUID           `json:"job_id"`
+	Provider     string              `json:"provider"`
+	TrackingCode string              `json:"tracking_code"`
+	Status       string              `json:"status"`
+	ErrorCode    string              `json:"error_code,omitempty"`
+	ErrorMessage string              `json:"error_message,omitempty"`
+	Shipment     *providers.Shipment `json:"shipment,omitempty"`
+}
+
+type StatusChangedData struct {
+	JobID          uuid.UUID `json:"job_id"`
+	Provider       string    `json:"provider"`
+	TrackingCode   string    `json:"tracking_code"`
+	PreviousStatus string    `json:"previous_status"`
+	Status         string    `json:"status"`
+	RawStatus      string    `json:"raw_status"`
+}
+
+type EventAddedData struct {
+	JobID        uuid.UUID               `json:"job_id"`
+	Provider     string                  `json:"provider"`
+	TrackingCode string                  `json:"tracking_code"`
+	Event        providers.TrackingEvent `json:"event"`
+}
+
+func JobEvent(data JobData, now time.Time) (Event, bool) {
+	var typ string
+	switch data.Status {
+	case "DONE":
+		typ = EventJobSucceeded
+	case "FAILED":
+		typ = EventJobFailed
+	default:
+		return Event{}, false
+	}
+	return Event{ID: uuid.New(), Type: typ, CreatedAt: now, Data: data}, true
+}
+
+// ShipmentEvents turns a timeline merge into one status change event, when
+// the status moved, and one event per newly seen tracking event.
+func ShipmentEvents(jobID uuid.UUID, shipment providers.Shipment, merge repo.MergeResult, now time.Time) []Event {
+	var events []Event
+	if merge.StatusChanged() {
+		events = append(events, Event{ID: uuid.New(), Type: EventShipmentStatusChanged, CreatedAt: now, Data: StatusChangedData{
+			JobID:          jobID,
+			Provider:       shipment.Provider,
+			TrackingCode:   shipment.TrackingCode,
+			PreviousStatus: merge.PreviousStatus,
+			Status:         merge.Status,
+			RawStatus:      shipment.RawStatus,
+		}})
+	}
+	for _, evt := range merge.NewEvents {
+		events = append(events, Event{ID: uuid.New(), Type: EventShipmentEventAdded, CreatedAt: now, Data: EventAddedData{
+			JobID:        jobID,
+			Provider:     shipment.Provider,
+			TrackingCode: shipment.TrackingCode,
+			Event:        evt,
+		}})
+	}
+	return events
+}
@@ -0,0 +1,55 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"logisync/internal/db/repo"
+	"logisync/internal/providers"
+)
+
+func TestValidateEvents(t *testing.T) {
+	if err := ValidateEvents(nil); err != nil {
+		t.Fatalf("expected empty filter to be valid, got %v", err)
+	}
+	if err := ValidateEvents([]string{EventJobFailed, EventShipmentEventAdded}); err != nil {
+		t.Fatalf("expected known events to be valid, got %v", err)
+	}
+	if err := ValidateEvents([]string{"job.exploded"}); err == nil {
+		t.Fatalf("expected unknown event to be rejected")
+	}
+}
+
+func TestJobEvent(t *testing.T) {
+	now := time.Now()
+	if evt, ok := JobEvent(JobData{Status: "DONE"}, now); !ok || evt.Type != EventJobSucceeded {
+		t.Fatalf("expected job.succeeded, got %+v %v", evt, ok)
+	}
+	if evt, ok := JobEvent(JobData{Status: "FAILED"}, now); !ok || evt.Type != EventJobFailed {
+		t.Fatalf("expected job.failed, got %+v %v", evt, ok)
+	}
+	if _, ok := JobEvent(JobData{Status: "RUNNING"}, now); ok {
+		t.Fatalf("expected no event for a running job")
+	}
+}
+
+func TestShipmentEvents(t *testing.T) {
+	shipment := providers.Shipment{Provider: "dummy", TrackingCode: "AA1", RawStatus: "Entregue"}
+	newEvt := providers.TrackingEvent{Timestamp: time.Now(), Description: "Entregue"}
+
+	events := ShipmentEvents(uuid.New(), shipment, repo.MergeResult{
+		PreviousStatus: "IN_TRANSIT",
+		Status:         "DELIVERED",
+		NewEvents:      []providers.TrackingEvent{newEvt},
+	}, time.Now())
+	if len(events) != 2 || events[0].Type != EventShipmentStatusChanged || events[1].Type != EventShipmentEventAdded {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+
+	first := ShipmentEvents(uuid.New(), shipment, repo.MergeResult{Status: "IN_TRANSIT"}, time.Now())
+	if len(first) != 0 {
+		t.Fatalf("expected first sighting without new events to publish nothing, got %+v", first)
+	}
+}
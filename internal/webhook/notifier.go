@@ -0,0 +1,74 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"logisync/internal/db/repo"
+	"logisync/internal/providers"
+)
+
+// Notifier records webhook deliveries for job and shipment changes. The
+// worker calls it next to JobRepo.MarkDone/MarkFailed and
+// ResultRepo.Insert/ShipmentRepo.Merge; the Deliverer does the sending.
+type Notifier struct {
+	webhooks *repo.WebhookRepo
+	now      func() time.Time
+}
+
+func NewNotifier(webhooks *repo.WebhookRepo) *Notifier {
+	return &Notifier{webhooks: webhooks, now: time.Now}
+}
+
+func (n *Notifier) Publish(ctx context.Context, evt Event) error {
+	payload, err := json.Marshal(evt)
+	if err != nil {
+		return fmt.Errorf("marshal webhook event: %w", err)
+	}
+	if _, err := n.webhooks.EnqueueDeliveries(ctx, evt.ID, evt.Type, payload); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (n *Notifier) JobSucceeded(ctx context.Context, job repo.Job, shipment providers.Shipment) error {
+	return n.publishJob(ctx, JobData{
+		JobID:        job.ID,
+		Provider:     job.Provider,
+		TrackingCode: job.TrackingCode,
+		Status:       "DONE",
+		Shipment:     &shipment,
+	})
+}
+
+func (n *Notifier) JobFailed(ctx context.Context, job repo.Job, code, message string) error {
+	return n.publishJob(ctx, JobData{
+		JobID:        job.ID,
+		Provider:     job.Provider,
+		TrackingCode: job.TrackingCode,
+		Status:       "FAILED",
+		ErrorCode:    code,
+		ErrorMessage: message,
+	})
+}
+
+func (n *Notifier) ShipmentMerged(ctx context.Context, jobID uuid.UUID, shipment providers.Shipment, merge repo.MergeResult) error {
+	for _, evt := range ShipmentEvents(jobID, shipment, merge, n.now().UTC()) {
+		if err := n.Publish(ctx, evt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *Notifier) publishJob(ctx context.Context, data JobData) error {
+	evt, ok := JobEvent(data, n.now().UTC())
+	if !ok {
+		return nil
+	}
+	return n.Publish(ctx, evt)
+}
@@ -0,0 +1,64 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-LogiSync-Signature"
+	EventHeader     = "X-LogiSync-Event"
+	DeliveryHeader  = "X-LogiSync-Delivery"
+)
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Sign returns the signature header value for body: "t=<unix>,v1=<hex>",
+// where v1 is HMAC-SHA256 over "<unix>.<body>" keyed by the endpoint secret.
+func Sign(secret string, ts time.Time, body []byte) string {
+	unix := strconv.FormatInt(ts.Unix(), 10)
+	return "t=" + unix + ",v1=" + signature(secret, unix, body)
+}
+
+// Verify checks a signature header produced by Sign and rejects timestamps
+// further than tolerance from now.
+func Verify(secret, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	var unix, sig string
+	for _, part := range strings.Split(header, ",") {
+		key, val, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			unix = val
+		case "v1":
+			sig = val
+		}
+	}
+	ts, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil || sig == "" {
+		return fmt.Errorf("%w: malformed header", ErrInvalidSignature)
+	}
+	if tolerance > 0 {
+		age := now.Sub(time.Unix(ts, 0))
+		if age > tolerance || age < -tolerance {
+			return fmt.Errorf("%w: timestamp outside tolerance", ErrInvalidSignature)
+		}
+	}
+	if !hmac.Equal([]byte(sig), []byte(signature(secret, unix, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func signature(secret, unix string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unix))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,29 @@
+package webhook
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	body := []byte(`{"type":"job.succeeded"}`)
+	header := Sign("s3cret", now, body)
+
+	if err := Verify("s3cret", header, body, now.Add(time.Minute), 5*time.Minute); err != nil {
+		t.Fatalf("expected valid signature, got %v", err)
+	}
+	if err := Verify("other", header, body, now, 5*time.Minute); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected wrong secret to fail, got %v", err)
+	}
+	if err := Verify("s3cret", header, []byte(`{}`), now, 5*time.Minute); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected tampered body to fail, got %v", err)
+	}
+	if err := Verify("s3cret", header, body, now.Add(10*time.Minute), 5*time.Minute); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected stale timestamp to fail, got %v", err)
+	}
+	if err := Verify("s3cret", "garbage", body, now, 0); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected malformed header to fail, got %v", err)
+	}
+}
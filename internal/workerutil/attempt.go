@@ -0,0 +1,30 @@
+package workerutil
+
+import (
+	"errors"
+
+	"logisync/internal/db/repo"
+	"logisync/internal/providers"
+)
+
+// AttemptOutcome classifies the error returned by Provider.Track for
+// AttemptRepo.Finish. retrying tells whether the job was rescheduled.
+func AttemptOutcome(err error, retrying bool) (outcome, code, message string) {
+	if err == nil {
+		return repo.AttemptSucceeded, "", ""
+	}
+
+	code = "PROVIDER_ERROR"
+	var providerErr *providers.Error
+	if errors.As(err, &providerErr) && providerErr.Code != "" {
+		code = providerErr.Code
+	}
+	switch {
+	case code == "CANCELLED":
+		return repo.AttemptCancelled, code, err.Error()
+	case retrying:
+		return repo.AttemptRetryScheduled, code, err.Error()
+	default:
+		return repo.AttemptFailed, code, err.Error()
+	}
+}
@@ -0,0 +1,33 @@
+package workerutil
+
+import (
+	"errors"
+	"testing"
+
+	"logisync/internal/db/repo"
+	"logisync/internal/providers"
+)
+
+func TestAttemptOutcome(t *testing.T) {
+	cases := []struct {
+		err      error
+		retrying bool
+		outcome  string
+		code     string
+	}{
+		{nil, false, repo.AttemptSucceeded, ""},
+		{&providers.Error{Code: "TIMEOUT", Message: "slow"}, true, repo.AttemptRetryScheduled, "TIMEOUT"},
+		{&providers.Error{Code: "INVALID_INPUT", Message: "bad"}, false, repo.AttemptFailed, "INVALID_INPUT"},
+		{&providers.Error{Code: "CANCELLED", Message: "stop"}, false, repo.AttemptCancelled, "CANCELLED"},
+		{errors.New("boom"), false, repo.AttemptFailed, "PROVIDER_ERROR"},
+	}
+	for _, tc := range cases {
+		outcome, code, message := AttemptOutcome(tc.err, tc.retrying)
+		if outcome != tc.outcome || code != tc.code {
+			t.Fatalf("AttemptOutcome(%v, %v) = %s %s, want %s %s", tc.err, tc.retrying, outcome, code, tc.outcome, tc.code)
+		}
+		if tc.err != nil && message == "" {
+			t.Fatalf("expected message for %v", tc.err)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package workerutil
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"logisync/internal/db/repo"
+)
+
+// LeaseExtender is implemented by *repo.JobRepo.
+type LeaseExtender interface {
+	ExtendLease(ctx context.Context, id uuid.UUID, owner string, lease time.Duration) (time.Time, error)
+}
+
+// Heartbeat extends jobID's lease every interval while the job runs. The
+// returned context is cancelled with repo.ErrLeaseLost as its cause once the
+// lease is gone, so Provider.Track stops working on a job another worker may
+// own by now; other errors go to onError and the next beat tries again. stop
+// must be called when the job finishes.
+func Heartbeat(ctx context.Context, jobs LeaseExtender, jobID uuid.UUID, owner string, lease, interval time.Duration, onError func(error)) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			_, err := jobs.ExtendLease(ctx, jobID, owner, lease)
+			switch {
+			case errors.Is(err, repo.ErrLeaseLost):
+				cancel(repo.ErrLeaseLost)
+				return
+			case err != nil && ctx.Err() == nil && onError != nil:
+				onError(err)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			cancel(context.Canceled)
+			<-done
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package workerutil
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"logisync/internal/db/repo"
+)
+
+type fakeExtender struct {
+	calls  atomic.Int32
+	loseAt int32
+}
+
+func (f *fakeExtender) ExtendLease(ctx context.Context, id uuid.UUID, owner string, lease time.Duration) (time.Time, error) {
+	if n := f.calls.Add(1); f.loseAt > 0 && n >= f.loseAt {
+		return time.Time{}, repo.ErrLeaseLost
+	}
+	return time.Now().Add(lease), nil
+}
+
+func TestHeartbeatCancelsOnLostLease(t *testing.T) {
+	ext := &fakeExtender{loseAt: 3}
+	ctx, stop := Heartbeat(context.Background(), ext, uuid.New(), "worker-1", time.Minute, 5*time.Millisecond, nil)
+	defer stop()
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("context not cancelled after lease loss")
+	}
+	if !errors.Is(context.Cause(ctx), repo.ErrLeaseLost) {
+		t.Fatalf("expected ErrLeaseLost cause, got %v", context.Cause(ctx))
+	}
+	if got := ext.calls.Load(); got != 3 {
+		t.Fatalf("expected 3 extensions, got %d", got)
+	}
+}
+
+func TestHeartbeatStop(t *testing.T) {
+	ext := &fakeExtender{}
+	ctx, stop := Heartbeat(context.Background(), ext, uuid.New(), "worker-1", time.Minute, 5*time.Millisecond, nil)
+	time.Sleep(30 * time.Millisecond)
+	stop()
+	stop()
+
+	if ctx.Err() == nil {
+		t.Fatal("context still live after stop")
+	}
+	if errors.Is(context.Cause(ctx), repo.ErrLeaseLost) {
+		t.Fatal("stop reported as lease loss")
+	}
+	calls := ext.calls.Load()
+	if calls == 0 {
+		t.Fatal("lease never extended")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if ext.calls.Load() != calls {
+		t.Fatal("lease extended after stop")
+	}
+}
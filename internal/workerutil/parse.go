@@ -35,3 +35,11 @@ func ParseMessage(values map[string]any) (uuid.UUID, string, string, bool) {
 	}
 	return jobID, provider, trackingCode, true
 }
+
+func NewMessage(jobID uuid.UUID, provider, trackingCode string) map[string]any {
+	return map[string]any{
+		"jobId":        jobID.String(),
+		"provider":     provider,
+		"trackingCode": trackingCode,
+	}
+}
@@ -40,3 +40,14 @@ func TestParseMessageInvalid(t *testing.T) {
 		t.Fatalf("expected not ok")
 	}
 }
+
+func TestNewMessageRoundTrip(t *testing.T) {
+	id := uuid.New()
+	jobID, provider, trackingCode, ok := ParseMessage(NewMessage(id, "dummy", "TEST123"))
+	if !ok {
+		t.Fatalf("expected ok")
+	}
+	if jobID != id || provider != "dummy" || trackingCode != "TEST123" {
+		t.Fatalf("unexpected round trip: %s %s %s", jobID, provider, trackingCode)
+	}
+}